@@ -0,0 +1,581 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"google.golang.org/protobuf/encoding/protojson"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AccessLogSink persists events. It is only ever called from the goroutine
+// of its buffer, so implementations need no locking. Flush is called
+// whenever the buffer runs empty.
+type AccessLogSink interface {
+	WriteEvent(e *Event) error
+	Flush() error
+	Close() error
+}
+
+type flushWriter interface {
+	io.Writer
+	Flush() error
+}
+
+// jsonLinesSink writes one JSON object per event, every line in a single
+// Write so rotation never splits it.
+type jsonLinesSink struct {
+	w      flushWriter
+	closer io.Closer
+}
+
+// NewStdoutSink writes events to stdout as JSON lines.
+func NewStdoutSink() AccessLogSink {
+	return &jsonLinesSink{w: bufio.NewWriter(os.Stdout)}
+}
+
+func (s *jsonLinesSink) WriteEvent(e *Event) error {
+	data, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = s.w.Write(append(data, '\n'))
+
+	return err
+}
+
+func (s *jsonLinesSink) Flush() error {
+	return s.w.Flush()
+}
+
+func (s *jsonLinesSink) Close() error {
+	err := s.w.Flush()
+	if s.closer != nil {
+		if cerr := s.closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
+
+// rotatingFile is appended to until it would grow past maxBytes, then it is
+// renamed to path.1, the previous path.1 to path.2 and so on, dropping
+// anything past path.<maxBackups>. Every Write lands in a single file.
+type rotatingFile struct {
+	path       string
+	maxBytes   int64
+	maxBackups int
+
+	f    *os.File
+	buf  *bufio.Writer
+	size int64
+}
+
+func openRotatingFile(path string, maxBytes int64, maxBackups int) (*rotatingFile, error) {
+	rf := &rotatingFile{path: path, maxBytes: maxBytes, maxBackups: maxBackups}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+
+	return rf, nil
+}
+
+func (rf *rotatingFile) open() error {
+	f, err := os.OpenFile(rf.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	rf.f = f
+	rf.buf = bufio.NewWriter(f)
+	rf.size = fi.Size()
+
+	return nil
+}
+
+func (rf *rotatingFile) Write(p []byte) (int, error) {
+	if rf.maxBytes > 0 && rf.size > 0 && rf.size+int64(len(p)) > rf.maxBytes {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := rf.buf.Write(p)
+	rf.size += int64(n)
+
+	return n, err
+}
+
+func (rf *rotatingFile) Flush() error {
+	return rf.buf.Flush()
+}
+
+func (rf *rotatingFile) rotate() error {
+	if err := rf.buf.Flush(); err != nil {
+		return err
+	}
+	if err := rf.f.Close(); err != nil {
+		return err
+	}
+
+	if rf.maxBackups > 0 {
+		os.Remove(rf.path + "." + strconv.Itoa(rf.maxBackups))
+		for i := rf.maxBackups - 1; i > 0; i-- {
+			os.Rename(rf.path+"."+strconv.Itoa(i), rf.path+"."+strconv.Itoa(i+1))
+		}
+		if err := os.Rename(rf.path, rf.path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Remove(rf.path); err != nil {
+		return err
+	}
+
+	return rf.open()
+}
+
+func (rf *rotatingFile) Close() error {
+	err := rf.buf.Flush()
+	if cerr := rf.f.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// NewJSONFileSink appends events as JSON lines to path, rotating it once it
+// would grow past maxBytes and keeping maxBackups rotated files.
+// Zero maxBytes disables rotation.
+func NewJSONFileSink(path string, maxBytes int64, maxBackups int) (AccessLogSink, error) {
+	rf, err := openRotatingFile(path, maxBytes, maxBackups)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jsonLinesSink{w: rf, closer: rf}, nil
+}
+
+// syslogSink formats events as RFC 5424 messages with facility local0.
+// Denied calls are logged as warnings, ACL changes as notices and the
+// rest as informational.
+type syslogSink struct {
+	w        io.Writer
+	closer   io.Closer
+	buf      *bufio.Writer // nil for sockets, every message is sent at once
+	framing  func(msg []byte) []byte
+	hostname string
+	appName  string
+	procID   string
+	escaper  *strings.Replacer // for structured data values
+
+	// sockets are dialed again after a failed write, retries times at most
+	// per event, waiting retryDelay doubled every time
+	dial       func() (net.Conn, error)
+	retries    int
+	retryDelay time.Duration
+}
+
+const (
+	syslogLocal0  = 16
+	syslogWarning = 4
+	syslogNotice  = 5
+	syslogInfo    = 6
+
+	// the private enterprise number reserved for documentation, RFC 5612
+	syslogSDSuffix = "@32473"
+)
+
+// NewSyslogSink sends events to a syslog daemon. network is one of unixgram,
+// udp, unix or tcp, stream transports use octet counting framing, RFC 6587.
+// When the daemon goes away the sink reconnects, the event being written
+// is retried for a few seconds before it is given up.
+func NewSyslogSink(network string, addr string, appName string) (AccessLogSink, error) {
+	conn, err := net.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := newSyslogSink(appName)
+	s.w, s.closer = conn, conn
+	s.dial = func() (net.Conn, error) {
+		return net.DialTimeout(network, addr, 5*time.Second)
+	}
+	s.retries, s.retryDelay = 6, 100*time.Millisecond
+	switch network {
+	case "unix", "tcp", "tcp4", "tcp6":
+		s.framing = func(msg []byte) []byte {
+			return append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+		}
+	}
+
+	return s, nil
+}
+
+// NewSyslogFileSink appends RFC 5424 messages to path, one per line.
+func NewSyslogFileSink(path string, appName string) (AccessLogSink, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	s := newSyslogSink(appName)
+	s.buf = bufio.NewWriter(f)
+	s.w, s.closer = s.buf, f
+	s.framing = func(msg []byte) []byte {
+		return append(msg, '\n')
+	}
+
+	return s, nil
+}
+
+func newSyslogSink(appName string) *syslogSink {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	return &syslogSink{
+		framing:  func(msg []byte) []byte { return msg },
+		hostname: hostname,
+		appName:  syslogName(appName),
+		procID:   strconv.Itoa(os.Getpid()),
+		escaper:  strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`),
+	}
+}
+
+// syslogName makes s fit a header field: printable ASCII without spaces,
+// at most 48 characters, "-" when empty.
+func syslogName(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c <= ' ' || c > '~' {
+			b[i] = '_'
+		}
+	}
+	if len(b) > 48 {
+		b = b[:48]
+	}
+	if len(b) == 0 {
+		return "-"
+	}
+
+	return string(b)
+}
+
+func (s *syslogSink) format(e *Event) []byte {
+	severity, msgID := syslogInfo, "call"
+	switch {
+	case e.Alert != nil:
+		severity, msgID = syslogWarning, "alert"
+	case e.AclChange != nil:
+		severity, msgID = syslogNotice, "acl"
+	case e.Result != nil:
+		msgID = "result"
+	case e.Denied:
+		severity = syslogWarning
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %s %s ",
+		syslogLocal0*8+severity,
+		time.Unix(e.Timestamp, 0).UTC().Format(time.RFC3339),
+		s.hostname, s.appName, s.procID, msgID)
+
+	b.WriteString("[event" + syslogSDSuffix)
+	param := func(name string, value string) {
+		if value != "" {
+			b.WriteString(" " + name + `="` + s.escaper.Replace(value) + `"`)
+		}
+	}
+	if e.Seq != 0 {
+		param("seq", strconv.FormatUint(e.Seq, 10))
+	}
+	param("consumer", e.Consumer)
+	param("method", e.Method)
+	param("host", e.Host)
+	param("trace_id", e.TraceId)
+	param("span_id", e.SpanId)
+	if e.Result != nil {
+		param("code", e.Result.Code)
+		param("latency_us", strconv.FormatInt(e.Result.LatencyMicros, 10))
+	}
+	if e.Denied {
+		param("denied", "true")
+	}
+	if e.Throttled {
+		param("throttled", "true")
+	}
+	b.WriteString("]")
+
+	msg := e.Reason
+	if e.Alert != nil {
+		msg = e.Alert.Rule + ": " + e.Alert.Message
+	}
+	if e.AclChange != nil {
+		msg = "acl updated from " + e.AclChange.Source
+		if e.AclChange.Error != "" {
+			msg = "acl from " + e.AclChange.Source + " rejected: " + e.AclChange.Error
+		}
+	}
+	if msg != "" {
+		// no BOM, the message is not guaranteed to be UTF-8
+		b.WriteString(" " + strings.ReplaceAll(msg, "\n", " "))
+	}
+
+	return []byte(b.String())
+}
+
+func (s *syslogSink) WriteEvent(e *Event) error {
+	msg := s.framing(s.format(e))
+	delay := s.retryDelay
+	for attempt := 0; ; attempt++ {
+		err := s.write(msg)
+		if err == nil || attempt >= s.retries {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// write sends msg, dialing first if the last write broke the connection.
+func (s *syslogSink) write(msg []byte) error {
+	if s.w == nil {
+		conn, err := s.dial()
+		if err != nil {
+			return err
+		}
+		s.w, s.closer = conn, conn
+	}
+
+	_, err := s.w.Write(msg)
+	if err != nil && s.dial != nil {
+		// a stream may have half a message in it, start over
+		s.closer.Close()
+		s.w, s.closer = nil, nil
+	}
+
+	return err
+}
+
+func (s *syslogSink) Flush() error {
+	if s.buf == nil {
+		return nil
+	}
+
+	return s.buf.Flush()
+}
+
+func (s *syslogSink) Close() error {
+	err := s.Flush()
+	if s.closer == nil {
+		return err
+	}
+	if cerr := s.closer.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// bufferedSink decouples a sink from the callers of EventSubs.Notify: events
+// are queued without blocking. When the queue is full they are dropped, or
+// with a spill directory appended to a file there and written to the sink
+// once it has caught up, in order.
+type bufferedSink struct {
+	name     string
+	sink     AccessLogSink
+	queue    chan *Event
+	queueMu  sync.RWMutex // guards sending to queue against closing it
+	closed   bool
+	spillDir string
+	spillMu  sync.Mutex // guards spill and spilling
+	spill    *spillFile
+	spilling bool // events go to spill until it is taken by run
+	dropped  uint64
+	spilled  uint64
+	failed   uint64
+	done     chan struct{}
+}
+
+type spillFile struct {
+	f *os.File
+	w *bufio.Writer
+}
+
+func newBufferedSink(name string, sink AccessLogSink, size int) *bufferedSink {
+	return newSpillingSink(name, sink, size, "")
+}
+
+// newSpillingSink is newBufferedSink that spills to dir instead of dropping.
+func newSpillingSink(name string, sink AccessLogSink, size int, dir string) *bufferedSink {
+	if dir != "" && size < 1 {
+		// run only looks at the spill file after an event from the queue
+		size = 1
+	}
+
+	bs := &bufferedSink{
+		name:     name,
+		sink:     sink,
+		queue:    make(chan *Event, size),
+		spillDir: dir,
+		done:     make(chan struct{}),
+	}
+	go bs.run()
+
+	return bs
+}
+
+func (bs *bufferedSink) Offer(e *Event) {
+	bs.queueMu.RLock()
+	defer bs.queueMu.RUnlock()
+
+	if bs.closed {
+		return
+	}
+
+	if bs.spillDir == "" {
+		select {
+		case bs.queue <- e:
+		default:
+			atomic.AddUint64(&bs.dropped, 1)
+		}
+		return
+	}
+
+	bs.spillMu.Lock()
+	defer bs.spillMu.Unlock()
+
+	// once spilling, queueing would put newer events before the spilled ones
+	if !bs.spilling {
+		select {
+		case bs.queue <- e:
+			return
+		default:
+		}
+	}
+	if err := bs.spillEvent(e); err != nil {
+		atomic.AddUint64(&bs.dropped, 1)
+		bs.fail(err)
+		return
+	}
+	bs.spilling = true
+	atomic.AddUint64(&bs.spilled, 1)
+}
+
+func (bs *bufferedSink) spillEvent(e *Event) error {
+	if bs.spill == nil {
+		f, err := os.CreateTemp(bs.spillDir, syslogName(bs.name)+"-*.spill")
+		if err != nil {
+			return err
+		}
+		bs.spill = &spillFile{f: f, w: bufio.NewWriter(f)}
+	}
+	_, err := writeRecord(bs.spill.w, e)
+
+	return err
+}
+
+func (bs *bufferedSink) run() {
+	defer close(bs.done)
+
+	for e := range bs.queue {
+		if err := bs.sink.WriteEvent(e); err != nil {
+			bs.fail(err)
+		}
+		if len(bs.queue) == 0 {
+			// everything queued is older than what has been spilled
+			bs.drainSpill()
+			if err := bs.sink.Flush(); err != nil {
+				bs.fail(err)
+			}
+		}
+	}
+
+	bs.drainSpill()
+	if err := bs.sink.Close(); err != nil {
+		bs.fail(err)
+	}
+}
+
+// drainSpill writes the spilled events to the sink. Events offered
+// meanwhile are queued again, they are newer.
+func (bs *bufferedSink) drainSpill() {
+	bs.spillMu.Lock()
+	spill := bs.spill
+	bs.spill, bs.spilling = nil, false
+	bs.spillMu.Unlock()
+
+	if spill == nil {
+		return
+	}
+	defer func() {
+		spill.f.Close()
+		os.Remove(spill.f.Name())
+	}()
+
+	err := spill.w.Flush()
+	if err == nil {
+		_, err = spill.f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		bs.fail(err)
+		return
+	}
+
+	r := bufio.NewReader(spill.f)
+	for {
+		e, _, err := readRecord(r)
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			bs.fail(err)
+			return
+		}
+		if err := bs.sink.WriteEvent(e); err != nil {
+			bs.fail(err)
+		}
+	}
+}
+
+// fail reports the first error and counts the rest, a broken disk would
+// flood the output otherwise.
+func (bs *bufferedSink) fail(err error) {
+	if atomic.AddUint64(&bs.failed, 1) == 1 {
+		fmt.Printf("access log %s: %v\n", bs.name, err)
+	}
+}
+
+// Stats returns how many events were dropped and how many writes failed.
+func (bs *bufferedSink) Stats() (uint64, uint64) {
+	return atomic.LoadUint64(&bs.dropped), atomic.LoadUint64(&bs.failed)
+}
+
+// Spilled returns how many events went through the spill file.
+func (bs *bufferedSink) Spilled() uint64 {
+	return atomic.LoadUint64(&bs.spilled)
+}
+
+// Close writes out what is queued or spilled and closes the sink.
+func (bs *bufferedSink) Close() {
+	bs.queueMu.Lock()
+	if !bs.closed {
+		bs.closed = true
+		close(bs.queue)
+	}
+	bs.queueMu.Unlock()
+
+	<-bs.done
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func readEvents(t *testing.T, path string) []*Event {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("cant open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	var events []*Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		e := &Event{}
+		if err := protojson.Unmarshal(scanner.Bytes(), e); err != nil {
+			t.Fatalf("bad line in %s %q: %v", path, scanner.Text(), err)
+		}
+		events = append(events, e)
+	}
+
+	return events
+}
+
+func TestJSONFileSinkRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	sink, err := NewJSONFileSink(path, 200, 2)
+	if err != nil {
+		t.Fatalf("cant open sink: %v", err)
+	}
+
+	for i := 1; i <= 20; i++ {
+		if err := sink.WriteEvent(&Event{Seq: uint64(i), Consumer: "biz_user", Method: "/main.Biz/Check"}); err != nil {
+			t.Fatalf("cant write: %v", err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("cant close: %v", err)
+	}
+
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("expected only 2 backups, got %v", err)
+	}
+
+	// newest events in path, older ones in path.1 and path.2 without gaps
+	var seqs []uint64
+	for _, name := range []string{path + ".2", path + ".1", path} {
+		fi, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("expected %s: %v", name, err)
+		}
+		if fi.Size() > 200 {
+			t.Fatalf("%s has grown to %d bytes", name, fi.Size())
+		}
+		for _, e := range readEvents(t, name) {
+			seqs = append(seqs, e.Seq)
+		}
+	}
+	if len(seqs) == 0 || seqs[len(seqs)-1] != 20 {
+		t.Fatalf("expected to end with the last event, got %v", seqs)
+	}
+	for i := 1; i < len(seqs); i++ {
+		if seqs[i] != seqs[i-1]+1 {
+			t.Fatalf("events are not consecutive: %v", seqs)
+		}
+	}
+}
+
+func TestSyslogFormat(t *testing.T) {
+	s := newSyslogSink("async logger")
+	s.hostname, s.procID = "box", "42"
+
+	for idx, tc := range []struct {
+		e        *Event
+		expected string
+	}{
+		{
+			&Event{Timestamp: 1767225600, Seq: 3, Consumer: "biz_user", Method: "/main.Biz/Test", Host: "127.0.0.1:5000",
+				Denied: true, Reason: `no rule of consumer "biz_user" matches`},
+			`<132>1 2026-01-01T00:00:00Z box async_logger 42 call [event@32473 seq="3" consumer="biz_user" method="/main.Biz/Test" host="127.0.0.1:5000" denied="true"] no rule of consumer "biz_user" matches`,
+		},
+		{
+			&Event{Timestamp: 1767225600, Consumer: `a"b]c\`, Method: "/main.Biz/Check", TraceId: "abc",
+				Result: &CallResult{Code: "OK", LatencyMicros: 120}},
+			`<134>1 2026-01-01T00:00:00Z box async_logger 42 result [event@32473 consumer="a\"b\]c\\" method="/main.Biz/Check" trace_id="abc" code="OK" latency_us="120"]`,
+		},
+		{
+			&Event{Timestamp: 1767225600, Seq: 4, Method: "/main.Admin/UpdateACL", AclChange: &AclChange{Source: "rpc", Error: "bad\njson"}},
+			`<133>1 2026-01-01T00:00:00Z box async_logger 42 acl [event@32473 seq="4" method="/main.Admin/UpdateACL"] acl from rpc rejected: bad json`,
+		},
+	} {
+		if have := string(s.format(tc.e)); have != tc.expected {
+			t.Errorf("[%d] format dont match\nhave %s\nwant %s", idx, have, tc.expected)
+		}
+	}
+}
+
+func TestSyslogSink(t *testing.T) {
+	addr := filepath.Join(t.TempDir(), "log.sock")
+	l, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: addr, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("cant listen: %v", err)
+	}
+	defer l.Close()
+
+	sink, err := NewSyslogSink("unixgram", addr, "async_logger")
+	if err != nil {
+		t.Fatalf("cant dial: %v", err)
+	}
+	defer sink.Close()
+
+	for _, method := range []string{"/main.Biz/Check", "/main.Biz/Add"} {
+		if err := sink.WriteEvent(&Event{Method: method}); err != nil {
+			t.Fatalf("cant write: %v", err)
+		}
+	}
+
+	// one datagram per message
+	buf := make([]byte, 4096)
+	for _, method := range []string{"/main.Biz/Check", "/main.Biz/Add"} {
+		n, err := l.Read(buf)
+		if err != nil {
+			t.Fatalf("cant read: %v", err)
+		}
+		msg := string(buf[:n])
+		if !strings.HasPrefix(msg, "<134>1 ") || !strings.HasSuffix(msg, `method="`+method+`"]`) {
+			t.Fatalf("unexpected message %q", msg)
+		}
+	}
+}
+
+func TestSyslogSinkReconnect(t *testing.T) {
+	addr := filepath.Join(t.TempDir(), "log.sock")
+	l, err := net.Listen("unix", addr)
+	if err != nil {
+		t.Fatalf("cant listen: %v", err)
+	}
+
+	sink, err := NewSyslogSink("unix", addr, "async_logger")
+	if err != nil {
+		t.Fatalf("cant dial: %v", err)
+	}
+	defer sink.Close()
+	sink.(*syslogSink).retryDelay = 10 * time.Millisecond
+
+	readMethod := func(l net.Listener) string {
+		conn, err := l.Accept()
+		if err != nil {
+			t.Fatalf("cant accept: %v", err)
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		size, err := r.ReadString(' ')
+		if err != nil {
+			t.Fatalf("cant read: %v", err)
+		}
+		n, _ := strconv.Atoi(strings.TrimSpace(size))
+		msg := make([]byte, n)
+		if _, err := io.ReadFull(r, msg); err != nil {
+			t.Fatalf("cant read: %v", err)
+		}
+		return string(msg[strings.Index(string(msg), "method="):])
+	}
+
+	if err := sink.WriteEvent(&Event{Method: "/main.Biz/Check"}); err != nil {
+		t.Fatalf("cant write: %v", err)
+	}
+	if method := readMethod(l); method != `method="/main.Biz/Check"]` {
+		t.Fatalf("unexpected message %q", method)
+	}
+
+	// the daemon restarts, taking a while to listen again
+	l.Close()
+	relisten := make(chan net.Listener)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		l, err := net.Listen("unix", addr)
+		if err != nil {
+			t.Errorf("cant listen again: %v", err)
+		}
+		relisten <- l
+	}()
+
+	if err := sink.WriteEvent(&Event{Method: "/main.Biz/Add"}); err != nil {
+		t.Fatalf("expected the sink to reconnect, got %v", err)
+	}
+	l = <-relisten
+	defer l.Close()
+	if method := readMethod(l); method != `method="/main.Biz/Add"]` {
+		t.Fatalf("unexpected message %q", method)
+	}
+}
+
+// gatedSink blocks every write until the test lets it through.
+type gatedSink struct {
+	gate    chan struct{}
+	written []uint64
+	closed  bool
+}
+
+func (s *gatedSink) WriteEvent(e *Event) error {
+	<-s.gate
+	s.written = append(s.written, e.Seq)
+	if e.Seq == 0 {
+		return fmt.Errorf("no seq")
+	}
+	return nil
+}
+
+func (s *gatedSink) Flush() error { return nil }
+
+func (s *gatedSink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestBufferedSink(t *testing.T) {
+	sink := &gatedSink{gate: make(chan struct{})}
+	bs := newBufferedSink("gated", sink, 2)
+
+	// the first event is taken and stuck in the sink, two more are
+	// buffered, the rest are dropped without blocking
+	for i := 1; i <= 6; i++ {
+		bs.Offer(&Event{Seq: uint64(i)})
+		if i == 1 {
+			wait(1)
+		}
+	}
+	bs.Offer(&Event{})
+	close(sink.gate)
+	bs.Close()
+	bs.Offer(&Event{Seq: 7})
+
+	if dropped, failed := bs.Stats(); dropped != 4 || failed != 0 {
+		t.Fatalf("expected 4 dropped and no failures, got %d and %d", dropped, failed)
+	}
+	if fmt.Sprint(sink.written) != "[1 2 3]" || !sink.closed {
+		t.Fatalf("expected events 1-3 and a closed sink, got %v %v", sink.written, sink.closed)
+	}
+}
+
+func TestBufferedSinkSpill(t *testing.T) {
+	dir := t.TempDir()
+	sink := &gatedSink{gate: make(chan struct{})}
+	bs := newSpillingSink("gated", sink, 2, dir)
+
+	// the first event is taken and stuck in the sink, two more are
+	// buffered, the rest go to the spill file
+	for i := 1; i <= 6; i++ {
+		bs.Offer(&Event{Seq: uint64(i)})
+		if i == 1 {
+			wait(1)
+		}
+	}
+	if spilled := bs.Spilled(); spilled != 3 {
+		t.Fatalf("expected 3 events spilled, got %d", spilled)
+	}
+
+	// what comes while the sink catches up is written after the spill
+	close(sink.gate)
+	for i := 7; i <= 20; i++ {
+		bs.Offer(&Event{Seq: uint64(i)})
+	}
+	bs.Close()
+
+	if dropped, failed := bs.Stats(); dropped != 0 || failed != 0 {
+		t.Fatalf("expected nothing dropped or failed, got %d and %d", dropped, failed)
+	}
+	for i, seq := range sink.written {
+		if seq != uint64(i+1) {
+			t.Fatalf("expected events 1-20 in order, got %v", sink.written)
+		}
+	}
+	if len(sink.written) != 20 || !sink.closed {
+		t.Fatalf("expected events 1-20 and a closed sink, got %v %v", sink.written, sink.closed)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Fatalf("expected the spill file to be removed, got %v", files)
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	dir := t.TempDir()
+	jsonSink, err := NewJSONFileSink(filepath.Join(dir, "access.log"), 0, 0)
+	if err != nil {
+		t.Fatalf("cant open sink: %v", err)
+	}
+	syslogSink, err := NewSyslogFileSink(filepath.Join(dir, "syslog"), "async_logger")
+	if err != nil {
+		t.Fatalf("cant open sink: %v", err)
+	}
+
+	ctx, finish := context.WithCancel(context.Background())
+	err = StartMyMicroservice(ctx, listenAddr, ACLData,
+		WithAccessLog("json", jsonSink, 64),
+		WithAccessLog("syslog", syslogSink, 64))
+	if err != nil {
+		t.Fatalf("cant start server initial: %v", err)
+	}
+	wait(1)
+
+	conn := getGrpcConn(t)
+	biz := NewBizClient(conn)
+
+	// nobody is subscribed to Logging, the access log gets everything anyway
+	biz.Check(getConsumerCtx("biz_user"), &CounterKey{})
+	biz.Test(getConsumerCtx("biz_user"), &CounterBatch{})
+
+	conn.Close()
+	finish()
+	wait(10)
+
+	var lines []string
+	for _, e := range readEvents(t, filepath.Join(dir, "access.log")) {
+		line := e.Method + " " + strconv.FormatBool(e.Denied)
+		if e.Result != nil {
+			line += " " + e.Result.Code
+		}
+		lines = append(lines, line)
+	}
+	expected := "/main.Biz/Check false,/main.Biz/Check false OK,/main.Biz/Test true,/main.Biz/Test true Unauthenticated"
+	if strings.Join(lines, ",") != expected {
+		t.Fatalf("access log dont match\nhave %s\nwant %s", strings.Join(lines, ","), expected)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "syslog"))
+	if err != nil {
+		t.Fatalf("cant read syslog: %v", err)
+	}
+	if n := strings.Count(string(data), "\n"); n != 4 || !strings.Contains(string(data), "<132>1 ") {
+		t.Fatalf("expected 4 messages with a warning, got:\n%s", data)
+	}
+}
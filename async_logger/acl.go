@@ -0,0 +1,469 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// aclRule is a single allow or deny pattern after groups have been expanded.
+type aclRule struct {
+	pattern string
+	parts   []string
+	deny    bool
+	owner   string // consumer the rule was written for
+	group   string // group the rule came from, if any
+}
+
+func (r aclRule) matches(methodParts []string) bool {
+	if len(r.parts) > len(methodParts) {
+		return false
+	}
+
+	// a pattern shorter than the method works as a prefix, so "/main.Biz"
+	// and "/*" keep covering whole services as they always did
+	for i, part := range r.parts {
+		if ok, _ := path.Match(part, methodParts[i]); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r aclRule) String() string {
+	source := "consumer " + r.owner
+	if r.group != "" {
+		source = "group @" + r.group + " of " + source
+	}
+
+	return fmt.Sprintf("%q (%s)", r.pattern, source)
+}
+
+type aclDecision struct {
+	allowed bool
+	reason  string
+}
+
+type aclConsumer struct {
+	Inherits []string        `json:"inherits"`
+	Allow    []string        `json:"allow"`
+	Deny     []string        `json:"deny"`
+	Limits   *consumerLimits `json:"limits"`
+	Tenant   string          `json:"tenant"`
+	Role     string          `json:"role"`
+}
+
+// roleSuperAdmin lets a consumer see the Admin streams of every tenant.
+const roleSuperAdmin = "super_admin"
+
+// UnmarshalJSON accepts a bare list of methods as a shorthand for allow.
+func (c *aclConsumer) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		return json.Unmarshal(data, &c.Allow)
+	}
+
+	type plain aclConsumer
+	return json.Unmarshal(data, (*plain)(c))
+}
+
+type aclDocument struct {
+	Groups    map[string][]string     `json:"groups"`
+	Consumers map[string]*aclConsumer `json:"consumers"`
+}
+
+type aclAuth struct {
+	consumers map[string][]aclRule
+	// effective rules by consumer as plain strings, deny rules start with "!"
+	rules map[string][]string
+	// rate limits and quotas are not inherited
+	limits map[string]*consumerLimits
+	// neither are tenants and roles, consumers without a tenant share
+	// the default one
+	tenants     map[string]string
+	superAdmins map[string]struct{}
+}
+
+// tenantScope limits what an Admin caller gets to see.
+type tenantScope struct {
+	tenant string
+	all    bool
+}
+
+// scope of consumer is its own tenant. Super admins see every tenant, and
+// so does everybody as long as the ACL does not define any.
+func (aa *aclAuth) scope(consumer string) tenantScope {
+	_, all := aa.superAdmins[consumer]
+
+	return tenantScope{tenant: aa.tenants[consumer], all: all || len(aa.tenants) == 0}
+}
+
+// newAclAuth parses either the legacy {"consumer": ["/pkg.Service/Method"]}
+// document or the structured {"groups": {...}, "consumers": {...}} one.
+func newAclAuth(aclData string) (*aclAuth, error) {
+	top := map[string]json.RawMessage{}
+	if err := json.Unmarshal([]byte(aclData), &top); err != nil {
+		return nil, fmt.Errorf("failed to parse ACL data: %s", err)
+	}
+
+	doc := &aclDocument{}
+	consumers, structured := top["consumers"]
+	if structured && bytes.HasPrefix(bytes.TrimSpace(consumers), []byte("{")) {
+		if err := json.Unmarshal([]byte(aclData), doc); err != nil {
+			return nil, fmt.Errorf("failed to parse ACL data: %s", err)
+		}
+	} else if err := json.Unmarshal([]byte(aclData), &doc.Consumers); err != nil {
+		return nil, fmt.Errorf("failed to parse ACL data: %s", err)
+	}
+
+	p := &aclParser{
+		doc:      doc,
+		groups:   map[string][]string{},
+		resolved: map[string][]aclRule{},
+		visiting: map[string]bool{},
+	}
+
+	auth := &aclAuth{
+		consumers:   make(map[string][]aclRule, len(doc.Consumers)),
+		rules:       make(map[string][]string, len(doc.Consumers)),
+		limits:      map[string]*consumerLimits{},
+		tenants:     map[string]string{},
+		superAdmins: map[string]struct{}{},
+	}
+
+	for consumer := range doc.Consumers {
+		rules, err := p.consumer(consumer)
+		if err != nil {
+			return nil, err
+		}
+
+		auth.consumers[consumer] = rules
+
+		if limits := doc.Consumers[consumer].Limits; limits != nil {
+			if err := limits.compile(p, consumer); err != nil {
+				return nil, err
+			}
+			auth.limits[consumer] = limits
+		}
+
+		c := doc.Consumers[consumer]
+		if c.Tenant != "" {
+			auth.tenants[consumer] = c.Tenant
+		}
+		switch c.Role {
+		case "":
+		case roleSuperAdmin:
+			auth.superAdmins[consumer] = struct{}{}
+		default:
+			return nil, fmt.Errorf("unknown role %q of consumer %s", c.Role, consumer)
+		}
+
+		for _, rule := range rules {
+			if rule.deny {
+				auth.rules[consumer] = append(auth.rules[consumer], "!"+rule.pattern)
+			} else {
+				auth.rules[consumer] = append(auth.rules[consumer], rule.pattern)
+			}
+		}
+	}
+
+	return auth, nil
+}
+
+func (aa *aclAuth) authorize(consumer string, method string) aclDecision {
+	rules, ok := aa.consumers[consumer]
+	if !ok {
+		return aclDecision{reason: fmt.Sprintf("unknown consumer %q", consumer)}
+	}
+
+	methodParts := strings.Split(method, "/")
+
+	for _, rule := range rules {
+		if rule.deny && rule.matches(methodParts) {
+			return aclDecision{reason: "denied by rule " + rule.String()}
+		}
+	}
+
+	for _, rule := range rules {
+		if !rule.deny && rule.matches(methodParts) {
+			return aclDecision{allowed: true, reason: "allowed by rule " + rule.String()}
+		}
+	}
+
+	return aclDecision{reason: fmt.Sprintf("no rule of consumer %s matches %s", consumer, method)}
+}
+
+type aclParser struct {
+	doc      *aclDocument
+	groups   map[string][]string
+	resolved map[string][]aclRule
+	visiting map[string]bool
+}
+
+func (p *aclParser) consumer(name string) ([]aclRule, error) {
+	if rules, ok := p.resolved[name]; ok {
+		return rules, nil
+	}
+	if name == "" {
+		return nil, fmt.Errorf("empty consumer name in ACL")
+	}
+	if p.visiting[name] {
+		return nil, fmt.Errorf("consumer %s inherits from itself", name)
+	}
+	p.visiting[name] = true
+	defer delete(p.visiting, name)
+
+	c := p.doc.Consumers[name]
+	if c == nil {
+		c = &aclConsumer{}
+	}
+
+	var rules []aclRule
+	for _, list := range []struct {
+		patterns []string
+		deny     bool
+	}{{c.Deny, true}, {c.Allow, false}} {
+		for _, pattern := range list.patterns {
+			expanded, err := p.expand(name, pattern)
+			if err != nil {
+				return nil, err
+			}
+			for _, rule := range expanded {
+				rule.deny = list.deny
+				rules = append(rules, rule)
+			}
+		}
+	}
+
+	for _, parent := range c.Inherits {
+		if _, ok := p.doc.Consumers[parent]; !ok {
+			return nil, fmt.Errorf("consumer %s inherits from unknown consumer %s", name, parent)
+		}
+
+		inherited, err := p.consumer(parent)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, inherited...)
+	}
+
+	p.resolved[name] = rules
+
+	return rules, nil
+}
+
+func (p *aclParser) expand(consumer string, pattern string) ([]aclRule, error) {
+	if !strings.HasPrefix(pattern, "@") {
+		parts := strings.Split(pattern, "/")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] != "" {
+			return nil, fmt.Errorf("bad method %q for consumer %s: expected /package.Service/Method", pattern, consumer)
+		}
+		for _, part := range parts {
+			if _, err := path.Match(part, ""); err != nil {
+				return nil, fmt.Errorf("bad method %q for consumer %s: %v", pattern, consumer, err)
+			}
+		}
+
+		return []aclRule{{pattern: pattern, parts: parts, owner: consumer}}, nil
+	}
+
+	patterns, err := p.group(strings.TrimPrefix(pattern, "@"))
+	if err != nil {
+		return nil, fmt.Errorf("consumer %s: %v", consumer, err)
+	}
+
+	rules := make([]aclRule, 0, len(patterns))
+	for _, member := range patterns {
+		expanded, err := p.expand(consumer, member)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, expanded...)
+	}
+	for i := range rules {
+		rules[i].group = strings.TrimPrefix(pattern, "@")
+	}
+
+	return rules, nil
+}
+
+// group flattens nested group references into plain method patterns.
+func (p *aclParser) group(name string) ([]string, error) {
+	if patterns, ok := p.groups[name]; ok {
+		return patterns, nil
+	}
+
+	members, ok := p.doc.Groups[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown group @%s", name)
+	}
+	if p.visiting["@"+name] {
+		return nil, fmt.Errorf("group @%s includes itself", name)
+	}
+	p.visiting["@"+name] = true
+	defer delete(p.visiting, "@"+name)
+
+	var patterns []string
+	for _, member := range members {
+		if !strings.HasPrefix(member, "@") {
+			patterns = append(patterns, member)
+			continue
+		}
+
+		nested, err := p.group(strings.TrimPrefix(member, "@"))
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, nested...)
+	}
+
+	p.groups[name] = patterns
+
+	return patterns, nil
+}
+
+// aclStore holds the live ACL. Readers never lock, updates are validated
+// before they replace the current rules and are announced to subscribers.
+type aclStore struct {
+	current atomic.Value // *aclAuth
+	mux     sync.Mutex
+	subs    *EventSubs
+}
+
+func newAclStore(auth *aclAuth, subs *EventSubs) *aclStore {
+	store := &aclStore{subs: subs}
+	store.current.Store(auth)
+
+	return store
+}
+
+func (as *aclStore) Load() *aclAuth {
+	return as.current.Load().(*aclAuth)
+}
+
+// Update swaps in aclData if it is valid and emits an audit event either way.
+func (as *aclStore) Update(aclData string, source string, consumer string, host string) (*AclChange, error) {
+	as.mux.Lock()
+	defer as.mux.Unlock()
+
+	change := &AclChange{Source: source}
+
+	auth, err := newAclAuth(aclData)
+	if err != nil {
+		change.Error = err.Error()
+	} else {
+		change.Diff = diffAcl(as.Load(), auth)
+		as.current.Store(auth)
+	}
+
+	as.subs.Notify(&Event{
+		Timestamp: time.Now().Unix(),
+		Consumer:  consumer,
+		Method:    "/main.Admin/UpdateACL",
+		Host:      host,
+		AclChange: change,
+	})
+
+	return change, err
+}
+
+// watch polls path and applies its contents every time they differ from
+// what was applied last, starting with applied, the ACL the server was
+// started with. A file that was changed before the start is applied at once.
+func (as *aclStore) watch(ctx context.Context, path string, interval time.Duration, applied string) {
+	last := []byte(applied)
+	check := func() {
+		data, err := os.ReadFile(path)
+		if err != nil || bytes.Equal(data, last) {
+			return
+		}
+		last = data
+
+		if _, err := as.Update(string(data), "file:"+path, "", ""); err != nil {
+			fmt.Printf("acl file %s rejected: %v\n", path, err)
+		}
+	}
+	check()
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			check()
+		}
+	}
+}
+
+func diffAcl(old, new *aclAuth) []*AclConsumerDiff {
+	consumers := map[string]struct{}{}
+	for consumer := range old.consumers {
+		consumers[consumer] = struct{}{}
+	}
+	for consumer := range new.consumers {
+		consumers[consumer] = struct{}{}
+	}
+
+	diff := []*AclConsumerDiff{}
+	for consumer := range consumers {
+		oldEntries, newEntries := old.entries(consumer), new.entries(consumer)
+		granted := subtract(newEntries, oldEntries)
+		revoked := subtract(oldEntries, newEntries)
+		if len(granted) == 0 && len(revoked) == 0 {
+			continue
+		}
+
+		diff = append(diff, &AclConsumerDiff{
+			Consumer: consumer,
+			Granted:  granted,
+			Revoked:  revoked,
+		})
+	}
+
+	sort.Slice(diff, func(i, j int) bool {
+		return diff[i].Consumer < diff[j].Consumer
+	})
+
+	return diff
+}
+
+// entries lists the rules of consumer followed by its tenant and role, so
+// that moving a consumer between tenants shows up in the diff as well.
+func (aa *aclAuth) entries(consumer string) []string {
+	entries := aa.rules[consumer]
+	if tenant, ok := aa.tenants[consumer]; ok {
+		entries = append(entries[:len(entries):len(entries)], "tenant:"+tenant)
+	}
+	if _, ok := aa.superAdmins[consumer]; ok {
+		entries = append(entries[:len(entries):len(entries)], "role:"+roleSuperAdmin)
+	}
+
+	return entries
+}
+
+func subtract(a, b []string) []string {
+	skip := make(map[string]struct{}, len(b))
+	for _, s := range b {
+		skip[s] = struct{}{}
+	}
+
+	var res []string
+	for _, s := range a {
+		if _, ok := skip[s]; !ok {
+			res = append(res, s)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+const structuredACLData string = `{
+	"groups": {
+		"biz_read":  ["/main.Biz/Check"],
+		"biz_write": ["/main.Biz/Add", "/main.Biz/Test"],
+		"biz_all":   ["@biz_read", "@biz_write"],
+		"admin":     ["/main.Admin/*"]
+	},
+	"consumers": {
+		"reader":     {"allow": ["@biz_read"]},
+		"writer":     {"inherits": ["reader"], "allow": ["@biz_write"], "deny": ["/main.Biz/Test"]},
+		"auditor":    {"inherits": ["writer"], "allow": ["/main.Biz/Test", "@admin"]},
+		"any_check":  {"allow": ["/*.*/Check"]},
+		"all_but":    {"allow": ["/*"], "deny": ["/main.Admin", "/main.Biz/T*"]},
+		"legacy":     ["/main.Biz/Add"]
+	}
+}`
+
+func TestACLRules(t *testing.T) {
+	auth, err := newAclAuth(structuredACLData)
+	if err != nil {
+		t.Fatalf("cant parse acl: %v", err)
+	}
+
+	for idx, tc := range []struct {
+		consumer string
+		method   string
+		allowed  bool
+		reason   string
+	}{
+		{"reader", "/main.Biz/Check", true, `allowed by rule "/main.Biz/Check" (group @biz_read of consumer reader)`},
+		{"reader", "/main.Biz/Add", false, "no rule of consumer reader matches /main.Biz/Add"},
+		{"writer", "/main.Biz/Check", true, `(group @biz_read of consumer reader)`},
+		{"writer", "/main.Biz/Add", true, `(group @biz_write of consumer writer)`},
+		{"writer", "/main.Biz/Test", false, `denied by rule "/main.Biz/Test" (consumer writer)`},
+		// deny is inherited and wins over a child's own allow
+		{"auditor", "/main.Biz/Test", false, `denied by rule "/main.Biz/Test" (consumer writer)`},
+		{"auditor", "/main.Admin/Logging", true, `(group @admin of consumer auditor)`},
+		{"any_check", "/main.Biz/Check", true, ""},
+		{"any_check", "/other.Service/Check", true, ""},
+		{"any_check", "/main.Biz/Add", false, ""},
+		{"all_but", "/main.Biz/Add", true, `allowed by rule "/*"`},
+		{"all_but", "/main.Biz/Test", false, `denied by rule "/main.Biz/T*"`},
+		{"all_but", "/main.Admin/Statistics", false, `denied by rule "/main.Admin"`},
+		{"legacy", "/main.Biz/Add", true, ""},
+		{"unknown", "/main.Biz/Add", false, `unknown consumer "unknown"`},
+	} {
+		decision := auth.authorize(tc.consumer, tc.method)
+		if decision.allowed != tc.allowed {
+			t.Errorf("[%d] %s %s: expected allowed=%v, got %v (%s)", idx, tc.consumer, tc.method, tc.allowed, decision.allowed, decision.reason)
+		}
+		if !strings.Contains(decision.reason, tc.reason) {
+			t.Errorf("[%d] %s %s: expected reason %q, got %q", idx, tc.consumer, tc.method, tc.reason, decision.reason)
+		}
+	}
+}
+
+func TestACLRulesErrors(t *testing.T) {
+	for idx, tc := range []struct {
+		data string
+		err  string
+	}{
+		{`{"a": ["main.Biz/Check"]}`, "bad method"},
+		{`{"a": ["/main.Biz/Che[ck"]}`, "syntax error in pattern"},
+		{`{"consumers": {"a": {"allow": ["@missing"]}}}`, "unknown group @missing"},
+		{`{"groups": {"g": ["@g"]}, "consumers": {"a": ["@g"]}}`, "group @g includes itself"},
+		{`{"consumers": {"a": {"inherits": ["b"]}, "b": {"inherits": ["a"]}}}`, "inherits from itself"},
+		{`{"consumers": {"a": {"inherits": ["b"]}}}`, "unknown consumer b"},
+		{`{"consumers": {"a": {"allow": "/main.Biz/Check"}}}`, "failed to parse ACL data"},
+		{`{"consumers": {"a": {"role": "admin"}}}`, `unknown role "admin" of consumer a`},
+	} {
+		_, err := newAclAuth(tc.data)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("[%d] expected error with %q, got %v", idx, tc.err, err)
+		}
+	}
+}
+
+func TestACLDiffStructured(t *testing.T) {
+	old, err := newAclAuth(structuredACLData)
+	if err != nil {
+		t.Fatalf("cant parse acl: %v", err)
+	}
+	new, err := newAclAuth(strings.Replace(structuredACLData, `"deny": ["/main.Biz/Test"]`, `"deny": []`, 1))
+	if err != nil {
+		t.Fatalf("cant parse acl: %v", err)
+	}
+
+	diff := diffAcl(old, new)
+	if len(diff) != 2 || diff[0].Consumer != "auditor" || diff[1].Consumer != "writer" {
+		t.Fatalf("expected auditor and writer in diff, got %v", diff)
+	}
+	for _, d := range diff {
+		if len(d.Granted) != 0 || len(d.Revoked) != 1 || d.Revoked[0] != "!/main.Biz/Test" {
+			t.Fatalf("expected revoked deny rule, got %v", d)
+		}
+	}
+}
+
+func TestACLTenants(t *testing.T) {
+	legacy, err := newAclAuth(ACLData)
+	if err != nil {
+		t.Fatalf("cant parse acl: %v", err)
+	}
+	old, err := newAclAuth(tenantACLData)
+	if err != nil {
+		t.Fatalf("cant parse acl: %v", err)
+	}
+
+	for idx, tc := range []struct {
+		auth     *aclAuth
+		consumer string
+		expected tenantScope
+	}{
+		// without tenants everybody sees everything as before
+		{legacy, "logger", tenantScope{all: true}},
+		{old, "a_user", tenantScope{tenant: "team_a"}},
+		{old, "a_admin", tenantScope{tenant: "team_a"}},
+		{old, "b_admin", tenantScope{tenant: "team_b"}},
+		{old, "root", tenantScope{tenant: "ops", all: true}},
+		{old, "shared", tenantScope{}},
+		{old, "unknown", tenantScope{}},
+	} {
+		if scope := tc.auth.scope(tc.consumer); scope != tc.expected {
+			t.Errorf("[%d] %s: expected %+v, got %+v", idx, tc.consumer, tc.expected, scope)
+		}
+	}
+
+	// tenants and roles are not inherited, moves show up in the diff
+	moved := strings.Replace(tenantACLData, `"b_user":  {"tenant": "team_b"`, `"b_user":  {"tenant": "team_a"`, 1)
+	moved = strings.Replace(moved, `"role": "super_admin", `, ``, 1)
+	new, err := newAclAuth(moved)
+	if err != nil {
+		t.Fatalf("cant parse acl: %v", err)
+	}
+
+	diff := diffAcl(old, new)
+	if len(diff) != 2 ||
+		diff[0].Consumer != "b_user" || !reflect.DeepEqual(diff[0].Granted, []string{"tenant:team_a"}) || !reflect.DeepEqual(diff[0].Revoked, []string{"tenant:team_b"}) ||
+		diff[1].Consumer != "root" || len(diff[1].Granted) != 0 || !reflect.DeepEqual(diff[1].Revoked, []string{"role:super_admin"}) {
+		t.Fatalf("unexpected diff %v", diff)
+	}
+}
+
+const aclReloadData string = `{
+	"logger1":   ["/main.Admin/Logging"],
+	"biz_user":  ["/main.Biz/Check", "/main.Biz/Add"],
+	"acl_admin": ["/main.Admin/UpdateACL"]
+}`
+
+func TestUpdateACL(t *testing.T) {
+	ctx, finish := context.WithCancel(context.Background())
+	err := StartMyMicroservice(ctx, listenAddr, aclReloadData)
+	if err != nil {
+		t.Fatalf("cant start server initial: %v", err)
+	}
+	wait(1)
+	defer func() {
+		finish()
+		wait(1)
+	}()
+
+	conn := getGrpcConn(t)
+	defer conn.Close()
+
+	biz := NewBizClient(conn)
+	adm := NewAdminClient(conn)
+
+	logStream, err := adm.Logging(getConsumerCtx("logger1"), &LogFilter{})
+	if err != nil {
+		t.Fatalf("cant open log stream: %v", err)
+	}
+	wait(1)
+
+	if _, err = biz.Check(getConsumerCtx("biz_user"), &CounterKey{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// malformed documents are rejected and the live ACL stays in place
+	for idx, doc := range []string{
+		"{.;",
+		`{"biz_user": ["main.Biz/Check"]}`,
+		`{"": ["/main.Biz/Check"]}`,
+	} {
+		_, err = adm.UpdateACL(getConsumerCtx("acl_admin"), &AclDocument{Json: doc})
+		if code := grpc.Code(err); code != codes.InvalidArgument {
+			t.Fatalf("[%d] expected InvalidArgument, got %v", idx, err)
+		}
+	}
+	if _, err = biz.Check(getConsumerCtx("biz_user"), &CounterKey{}); err != nil {
+		t.Fatalf("rejected ACL broke the live one: %v", err)
+	}
+
+	change, err := adm.UpdateACL(getConsumerCtx("acl_admin"), &AclDocument{Json: `{
+		"logger1":   ["/main.Admin/Logging"],
+		"biz_user":  ["/main.Biz/Add", "/main.Biz/Test"],
+		"acl_admin": ["/main.Admin/UpdateACL"]
+	}`})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(change.Diff) != 1 || change.Diff[0].Consumer != "biz_user" ||
+		!reflect.DeepEqual(change.Diff[0].Granted, []string{"/main.Biz/Test"}) ||
+		!reflect.DeepEqual(change.Diff[0].Revoked, []string{"/main.Biz/Check"}) {
+		t.Fatalf("unexpected diff: %v", change.Diff)
+	}
+
+	_, err = biz.Check(getConsumerCtx("biz_user"), &CounterKey{})
+	if code := grpc.Code(err); code != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated after revoke, got %v", err)
+	}
+	if _, err = biz.Test(getConsumerCtx("biz_user"), &CounterBatch{}); err != nil {
+		t.Fatalf("unexpected error after grant: %v", err)
+	}
+
+	audit := []*AclChange{}
+	for len(audit) < 4 {
+		evt, err := logStream.Recv()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if evt.AclChange != nil {
+			if evt.Consumer != "acl_admin" || evt.Method != "/main.Admin/UpdateACL" {
+				t.Fatalf("bad audit event: %v", evt)
+			}
+			audit = append(audit, evt.AclChange)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if audit[i].Error == "" || audit[i].Source != "rpc" {
+			t.Fatalf("[%d] expected rejected audit event, got %v", i, audit[i])
+		}
+	}
+	if audit[3].Error != "" || len(audit[3].Diff) != 1 {
+		t.Fatalf("expected applied audit event, got %v", audit[3])
+	}
+}
+
+func TestACLFile(t *testing.T) {
+	path := t.TempDir() + "/acl.json"
+	if err := os.WriteFile(path, []byte(aclReloadData), 0600); err != nil {
+		t.Fatalf("cant write acl file: %v", err)
+	}
+
+	ctx, finish := context.WithCancel(context.Background())
+	err := StartMyMicroservice(ctx, listenAddr, aclReloadData, WithACLFile(path, 10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("cant start server initial: %v", err)
+	}
+	wait(1)
+	defer func() {
+		finish()
+		wait(1)
+	}()
+
+	conn := getGrpcConn(t)
+	defer conn.Close()
+
+	biz := NewBizClient(conn)
+
+	if _, err = biz.Add(getConsumerCtx("biz_user"), &CounterDelta{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("{.;"), 0600); err != nil {
+		t.Fatalf("cant write acl file: %v", err)
+	}
+	wait(5)
+	if _, err = biz.Add(getConsumerCtx("biz_user"), &CounterDelta{}); err != nil {
+		t.Fatalf("broken acl file was applied: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"biz_user": ["/main.Biz/Check"]}`), 0600); err != nil {
+		t.Fatalf("cant write acl file: %v", err)
+	}
+	wait(5)
+	_, err = biz.Add(getConsumerCtx("biz_user"), &CounterDelta{})
+	if code := grpc.Code(err); code != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated after reload, got %v", err)
+	}
+}
+
+func TestACLFileAtStart(t *testing.T) {
+	// the file was edited while the server was down
+	path := t.TempDir() + "/acl.json"
+	if err := os.WriteFile(path, []byte(`{"biz_user": ["/main.Biz/Check"]}`), 0600); err != nil {
+		t.Fatalf("cant write acl file: %v", err)
+	}
+
+	ctx, finish := context.WithCancel(context.Background())
+	err := StartMyMicroservice(ctx, listenAddr, aclReloadData, WithACLFile(path, time.Hour))
+	if err != nil {
+		t.Fatalf("cant start server initial: %v", err)
+	}
+	wait(1)
+	defer func() {
+		finish()
+		wait(1)
+	}()
+
+	conn := getGrpcConn(t)
+	defer conn.Close()
+
+	biz := NewBizClient(conn)
+	if _, err = biz.Add(getConsumerCtx("biz_user"), &CounterDelta{}); err == nil {
+		t.Fatalf("expected the acl file to be applied at start")
+	}
+	if _, err = biz.Check(getConsumerCtx("biz_user"), &CounterKey{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
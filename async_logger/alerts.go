@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// AlertRule looks at every call and reports when something is off. Rules
+// are only ever called from the goroutine of the alerter, so they keep
+// their state without locking.
+type AlertRule interface {
+	Validate() error
+	Evaluate(e *Event, now time.Time) *Alert
+}
+
+// DeniedCallsRule fires when a consumer gets more than Threshold calls
+// denied within Window, once per Window. Throttled calls do not count.
+type DeniedCallsRule struct {
+	Name      string
+	Consumer  string // pattern, empty for all; every consumer is counted separately
+	Threshold int
+	Window    time.Duration
+
+	denied    map[string][]time.Time
+	fired     map[string]time.Time
+	lastSweep time.Time
+}
+
+func (r *DeniedCallsRule) Validate() error {
+	if r.Name == "" || r.Threshold <= 0 || r.Window <= 0 {
+		return fmt.Errorf("rule %q: name, threshold and window are required", r.Name)
+	}
+	if _, err := path.Match(r.Consumer, ""); err != nil {
+		return fmt.Errorf("rule %q: bad consumer %q: %v", r.Name, r.Consumer, err)
+	}
+
+	return nil
+}
+
+func (r *DeniedCallsRule) Evaluate(e *Event, now time.Time) *Alert {
+	if r.denied == nil {
+		r.denied = map[string][]time.Time{}
+		r.fired = map[string]time.Time{}
+	}
+	since := now.Add(-r.Window)
+
+	// unknown consumers come and go, forget those that went quiet
+	if now.Sub(r.lastSweep) > r.Window {
+		for consumer, times := range r.denied {
+			if times[len(times)-1].Before(since) {
+				delete(r.denied, consumer)
+				delete(r.fired, consumer)
+			}
+		}
+		r.lastSweep = now
+	}
+
+	if !e.Denied || e.Throttled || !matchRule(r.Consumer, e.Consumer) {
+		return nil
+	}
+
+	times := r.denied[e.Consumer]
+	for len(times) > 0 && times[0].Before(since) {
+		times = times[1:]
+	}
+	times = append(times, now)
+	if len(times) > r.Threshold+1 {
+		// enough to tell it is over the threshold
+		times = times[len(times)-r.Threshold-1:]
+	}
+	r.denied[e.Consumer] = times
+
+	if len(times) <= r.Threshold {
+		return nil
+	}
+	if fired, ok := r.fired[e.Consumer]; ok && fired.After(since) {
+		return nil
+	}
+	r.fired[e.Consumer] = now
+
+	return &Alert{
+		Rule:     r.Name,
+		Consumer: e.Consumer,
+		Tenant:   e.Tenant,
+		Count:    uint64(len(times)),
+		Message:  fmt.Sprintf("consumer %q was denied more than %d times in %s", e.Consumer, r.Threshold, r.Window),
+	}
+}
+
+// RateSpikeRule fires when a method is called more than Factor times as
+// often in the current Bucket as on average in the Trailing buckets before
+// it, once per bucket. Buckets with fewer than MinCalls calls are ignored,
+// and so are methods seen for less than Trailing buckets.
+//
+// Method is matched like in LogFilter, where * stops at a slash: it is
+// /main.Biz/* for the methods of Biz and /*/* for all of them, as is an
+// empty Method.
+type RateSpikeRule struct {
+	Name     string
+	Method   string // pattern, every method of every tenant is counted separately
+	Factor   float64
+	Bucket   time.Duration
+	Trailing int
+	MinCalls int
+
+	rates map[string]*methodRate
+}
+
+type methodRate struct {
+	first  int64 // bucket of the first call
+	bucket int64
+	counts []int // ring of Trailing+1 buckets
+	fired  bool
+}
+
+func (r *RateSpikeRule) Validate() error {
+	if r.Name == "" || r.Factor <= 0 || r.Bucket <= 0 || r.Trailing <= 0 {
+		return fmt.Errorf("rule %q: name, factor, bucket and trailing are required", r.Name)
+	}
+	if _, err := path.Match(r.Method, ""); err != nil {
+		return fmt.Errorf("rule %q: bad method %q: %v", r.Name, r.Method, err)
+	}
+	if r.Method != "" && !strings.HasPrefix(r.Method, "/") {
+		return fmt.Errorf("rule %q: method %q never matches, methods look like /main.Biz/Check", r.Name, r.Method)
+	}
+
+	return nil
+}
+
+func (r *RateSpikeRule) Evaluate(e *Event, now time.Time) *Alert {
+	if e.Denied || !matchRule(r.Method, e.Method) {
+		return nil
+	}
+	if r.rates == nil {
+		r.rates = map[string]*methodRate{}
+	}
+
+	bucket := now.UnixNano() / int64(r.Bucket)
+	key := e.Tenant + " " + e.Method
+	rate, ok := r.rates[key]
+	if !ok {
+		rate = &methodRate{first: bucket, bucket: bucket, counts: make([]int, r.Trailing+1)}
+		r.rates[key] = rate
+	}
+
+	// clear the buckets that have passed without calls
+	for b := rate.bucket + 1; b <= bucket && b <= rate.bucket+int64(len(rate.counts)); b++ {
+		rate.counts[b%int64(len(rate.counts))] = 0
+	}
+	if bucket != rate.bucket {
+		rate.bucket, rate.fired = bucket, false
+	}
+
+	current := &rate.counts[bucket%int64(len(rate.counts))]
+	*current++
+
+	if rate.fired || *current < r.MinCalls || bucket-rate.first < int64(r.Trailing) {
+		return nil
+	}
+
+	total := 0
+	for _, count := range rate.counts {
+		total += count
+	}
+	average := float64(total-*current) / float64(r.Trailing)
+	if float64(*current) <= r.Factor*average {
+		return nil
+	}
+	rate.fired = true
+
+	return &Alert{
+		Rule:    r.Name,
+		Method:  e.Method,
+		Tenant:  e.Tenant,
+		Count:   uint64(*current),
+		Message: fmt.Sprintf("%s was called %d times in %s, %.1f on average before", e.Method, *current, r.Bucket, average),
+	}
+}
+
+// matchRule matches s against the pattern of a rule, an empty one
+// matches everything.
+func matchRule(pattern string, s string) bool {
+	return pattern == "" || matchAny([]string{pattern}, s)
+}
+
+// alerter runs the rules over the calls and publishes what they report as
+// events of their own. It is fed through a bufferedSink, so a burst of
+// calls never slows the server down.
+type alerter struct {
+	rules []AlertRule
+	subs  *EventSubs
+	now   func() time.Time
+}
+
+func newAlerter(rules []AlertRule, subs *EventSubs) *alerter {
+	return &alerter{rules: rules, subs: subs, now: time.Now}
+}
+
+func (a *alerter) WriteEvent(e *Event) error {
+	// results repeat the calls, the rest is not a call
+	if e.Result != nil || e.AclChange != nil || e.Alert != nil {
+		return nil
+	}
+
+	now := a.now()
+	for _, rule := range a.rules {
+		alert := rule.Evaluate(e, now)
+		if alert == nil {
+			continue
+		}
+
+		alert.Timestamp = now.Unix()
+		a.subs.Notify(&Event{
+			Timestamp: alert.Timestamp,
+			Consumer:  alert.Consumer,
+			Method:    alert.Method,
+			Tenant:    alert.Tenant,
+			Alert:     alert,
+		})
+	}
+
+	return nil
+}
+
+func (a *alerter) Flush() error { return nil }
+
+func (a *alerter) Close() error { return nil }
+
+// Alerts streams what the alert rules report. Like Logging it only shows
+// the alerts of the tenant of the caller.
+func (s *AdminServerImpl) Alerts(_ *Nothing, srv Admin_AlertsServer) error {
+	sub := s.subs.NewSub((&eventFilter{alerts: true}).within(s.scope(srv.Context())))
+	defer s.subs.RemoveSub(sub.ID)
+
+	for e := range sub.Events {
+		if err := srv.Send(alertOf(e, sub.TakeDropped())); err != nil {
+			return err
+		}
+	}
+
+	return sub.Err()
+}
+
+// alertOf is the alert of e as sent to subscribers.
+func alertOf(e *Event, dropped uint64) *Alert {
+	alert := proto.Clone(e.Alert).(*Alert)
+	alert.Seq = e.Seq
+	alert.Dropped = dropped
+
+	return alert
+}
+
+// webhookSink posts every alert as JSON to url. A failed post is not
+// retried, the alert is still in the Alerts streams and the journal.
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+// defaultWebhookTimeout bounds a post when no timeout is given, so that a
+// hung webhook can not hold up the shutdown.
+const defaultWebhookTimeout = 5 * time.Second
+
+func newWebhookSink(url string, timeout time.Duration) *webhookSink {
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+
+	return &webhookSink{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (s *webhookSink) WriteEvent(e *Event) error {
+	data, err := protojson.Marshal(alertOf(e, 0))
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook %s answered %s", s.url, resp.Status)
+	}
+
+	return nil
+}
+
+func (s *webhookSink) Flush() error { return nil }
+
+func (s *webhookSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestDeniedCallsRule(t *testing.T) {
+	rule := &DeniedCallsRule{Name: "misuse", Consumer: "biz_*", Threshold: 3, Window: 10 * time.Second}
+	start := time.Unix(1767225600, 0)
+
+	var fired []string
+	for _, call := range []struct {
+		second   int
+		consumer string
+		denied   bool
+	}{
+		{0, "biz_user", true},
+		{1, "biz_user", true},
+		{1, "biz_admin", true},
+		{2, "biz_user", false},
+		{2, "logger", true},
+		{3, "biz_user", true},
+		{4, "biz_user", true}, // 4 in 10 seconds
+		{5, "biz_user", true}, // already reported
+		{12, "biz_user", true},
+		{20, "biz_user", true},
+		{21, "biz_user", true},
+		{22, "biz_user", true}, // 4 again, the last alert is over 10 seconds old
+	} {
+		e := &Event{Consumer: call.consumer, Denied: call.denied}
+		if alert := rule.Evaluate(e, start.Add(time.Duration(call.second)*time.Second)); alert != nil {
+			fired = append(fired, call.consumer+" "+alert.Message)
+		}
+	}
+
+	// throttling is not misuse
+	for i := 0; i < 5; i++ {
+		if alert := rule.Evaluate(&Event{Consumer: "biz_admin", Denied: true, Throttled: true}, start.Add(30*time.Second)); alert != nil {
+			t.Fatalf("unexpected alert for throttled calls: %v", alert)
+		}
+	}
+
+	expected := []string{
+		`biz_user consumer "biz_user" was denied more than 3 times in 10s`,
+		`biz_user consumer "biz_user" was denied more than 3 times in 10s`,
+	}
+	if !reflect.DeepEqual(fired, expected) {
+		t.Fatalf("alerts dont match\nhave %q\nwant %q", fired, expected)
+	}
+}
+
+func TestRateSpikeRule(t *testing.T) {
+	rule := &RateSpikeRule{Name: "spike", Method: "/main.Biz/*", Factor: 2, Bucket: time.Second, Trailing: 3, MinCalls: 4}
+	start := time.Unix(1767225600, 0)
+
+	var fired []string
+	call := func(second int, calls int, method string) {
+		for i := 0; i < calls; i++ {
+			e := &Event{Method: method}
+			if alert := rule.Evaluate(e, start.Add(time.Duration(second)*time.Second)); alert != nil {
+				fired = append(fired, alert.Message)
+			}
+		}
+	}
+
+	call(0, 2, "/main.Biz/Check")
+	call(1, 10, "/main.Biz/Check") // no history yet
+	call(2, 2, "/main.Biz/Check")
+	call(3, 2, "/main.Biz/Check")
+	call(4, 9, "/main.Biz/Check")  // just under twice (10+2+2)/3
+	call(5, 12, "/main.Biz/Check") // reported once at 9 over (2+2+9)/3
+	call(5, 12, "/main.Admin/Logging")
+	call(6, 3, "/main.Biz/Check") // below MinCalls
+	call(20, 4, "/main.Biz/Check")
+
+	expected := []string{
+		"/main.Biz/Check was called 9 times in 1s, 4.3 on average before",
+		"/main.Biz/Check was called 4 times in 1s, 0.0 on average before",
+	}
+	if !reflect.DeepEqual(fired, expected) {
+		t.Fatalf("alerts dont match\nhave %q\nwant %q", fired, expected)
+	}
+}
+
+func TestAlertRulesMatchAll(t *testing.T) {
+	start := time.Unix(1767225600, 0)
+
+	// an empty pattern is every consumer and every method
+	denied := &DeniedCallsRule{Name: "misuse", Threshold: 1, Window: time.Minute}
+	spike := &RateSpikeRule{Name: "spike", Factor: 2, Bucket: time.Second, Trailing: 1, MinCalls: 1}
+	for _, rule := range []AlertRule{denied, spike} {
+		if err := rule.Validate(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	var deniedAlert, spikeAlert *Alert
+	for i := 0; i < 2; i++ {
+		if alert := denied.Evaluate(&Event{Consumer: "biz_user", Method: "/main.Biz/Check", Denied: true}, start); alert != nil {
+			deniedAlert = alert
+		}
+	}
+	spike.Evaluate(&Event{Consumer: "biz_user", Method: "/main.Biz/Check"}, start)
+	for i := 0; i < 3; i++ {
+		if alert := spike.Evaluate(&Event{Consumer: "biz_user", Method: "/main.Biz/Check"}, start.Add(time.Second)); alert != nil {
+			spikeAlert = alert
+		}
+	}
+	if deniedAlert == nil || deniedAlert.Consumer != "biz_user" {
+		t.Fatalf("expected a denied calls alert for biz_user, got %v", deniedAlert)
+	}
+	if spikeAlert == nil || spikeAlert.Method != "/main.Biz/Check" {
+		t.Fatalf("expected a rate spike alert for /main.Biz/Check, got %v", spikeAlert)
+	}
+
+	// * stops at a slash, it would never match a method
+	for _, method := range []string{"*", "main.Biz/*"} {
+		rule := &RateSpikeRule{Name: "spike", Method: method, Factor: 2, Bucket: time.Second, Trailing: 1}
+		if err := rule.Validate(); err == nil {
+			t.Errorf("expected method %q to be rejected", method)
+		}
+	}
+}
+
+func TestAlertsAreNotCalls(t *testing.T) {
+	subs := newEventSubs(16, DropNewest)
+	metrics := newServerMetrics(subs)
+	control := newStatisticsCollector()
+	subs.Observe(control.Update)
+
+	scrape := func() string {
+		w := httptest.NewRecorder()
+		metrics.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		return w.Body.String()
+	}
+
+	subs.Notify(&Event{Consumer: "biz_user", Method: "/main.Biz/Test", Denied: true})
+	subs.Notify(&Event{Consumer: "biz_user", Method: "/main.Biz/Test", Result: &CallResult{Code: "Unauthenticated"}})
+	stat, text := control.Snapshot(), scrape()
+
+	alert := &Event{
+		Consumer: "biz_user",
+		Method:   "/main.Biz/Test",
+		Alert:    &Alert{Rule: "misuse", Consumer: "biz_user", Method: "/main.Biz/Test", Count: 1},
+	}
+	subs.Notify(alert)
+
+	if have := control.Snapshot(); !proto.Equal(have, stat) {
+		t.Errorf("statistics changed by an alert\nhave %v\nwant %v", have, stat)
+	}
+	if have := scrape(); have != text {
+		t.Errorf("metrics changed by an alert\nhave %s\nwant %s", have, text)
+	}
+
+	for _, outcome := range []LogFilter_Outcome{LogFilter_ALLOWED, LogFilter_DENIED} {
+		filter, _ := newEventFilter(&LogFilter{Outcome: outcome})
+		if filter.Match(alert) {
+			t.Errorf("an alert matched outcome %v", outcome)
+		}
+	}
+}
+
+func TestWebhookDefaultTimeout(t *testing.T) {
+	if timeout := newWebhookSink("http://127.0.0.1:1/", 0).client.Timeout; timeout != defaultWebhookTimeout {
+		t.Fatalf("expected %s, got %s", defaultWebhookTimeout, timeout)
+	}
+}
+
+const alertsACLData string = `{
+	"watcher":  ["/main.Admin/Alerts"],
+	"biz_user": ["/main.Biz/Check"]
+}`
+
+func TestAlerts(t *testing.T) {
+	posted := make(chan *Alert, 10)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		alert := &Alert{}
+		if err := protojson.Unmarshal(data, alert); err != nil {
+			t.Errorf("bad webhook body %q: %v", data, err)
+		}
+		posted <- alert
+	}))
+	defer webhook.Close()
+
+	rule := &DeniedCallsRule{Name: "misuse", Consumer: "*", Threshold: 2, Window: time.Minute}
+	if err := StartMyMicroservice(context.Background(), listenAddr, alertsACLData, WithAlertRules(&DeniedCallsRule{Name: "misuse"})); err == nil {
+		t.Fatalf("expected an incomplete rule to be rejected")
+	}
+
+	ctx, finish := context.WithCancel(context.Background())
+	err := StartMyMicroservice(ctx, listenAddr, alertsACLData,
+		WithAlertRules(rule),
+		WithAlertWebhook(webhook.URL, time.Second))
+	if err != nil {
+		t.Fatalf("cant start server initial: %v", err)
+	}
+	wait(1)
+	defer func() {
+		finish()
+		wait(1)
+	}()
+
+	conn := getGrpcConn(t)
+	defer conn.Close()
+
+	alerts, err := NewAdminClient(conn).Alerts(getConsumerCtx("watcher"), &Nothing{})
+	if err != nil {
+		t.Fatalf("cant open alerts stream: %v", err)
+	}
+	wait(1)
+
+	biz := NewBizClient(conn)
+	for i := 0; i < 3; i++ {
+		biz.Check(getConsumerCtx("biz_user"), &CounterKey{})
+		biz.Test(getConsumerCtx("biz_user"), &CounterBatch{})
+		biz.Check(getConsumerCtx("intruder"), &CounterKey{})
+	}
+
+	var received []string
+	for i := 0; i < 2; i++ {
+		alert, err := alerts.Recv()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if alert.Rule != "misuse" || alert.Count != 3 || alert.Seq == 0 || alert.Timestamp == 0 {
+			t.Fatalf("unexpected alert %v", alert)
+		}
+		received = append(received, alert.Consumer)
+	}
+
+	var webhooks []string
+	for i := 0; i < 2; i++ {
+		select {
+		case alert := <-posted:
+			webhooks = append(webhooks, alert.Consumer)
+		case <-time.After(time.Second):
+			t.Fatalf("webhook has not been called")
+		}
+	}
+
+	expected := []string{"biz_user", "intruder"}
+	if !reflect.DeepEqual(received, expected) || !reflect.DeepEqual(webhooks, expected) {
+		t.Fatalf("expected alerts for %v, got %v on the stream and %v by the webhook", expected, received, webhooks)
+	}
+}
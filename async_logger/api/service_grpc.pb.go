@@ -0,0 +1,521 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v6.31.1
+// source: service.proto
+
+package api
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// AdminClient is the client API for Admin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AdminClient interface {
+	Logging(ctx context.Context, in *LogFilter, opts ...grpc.CallOption) (Admin_LoggingClient, error)
+	Statistics(ctx context.Context, in *StatInterval, opts ...grpc.CallOption) (Admin_StatisticsClient, error)
+	UpdateACL(ctx context.Context, in *AclDocument, opts ...grpc.CallOption) (*AclChange, error)
+	Control(ctx context.Context, opts ...grpc.CallOption) (Admin_ControlClient, error)
+	Alerts(ctx context.Context, in *Nothing, opts ...grpc.CallOption) (Admin_AlertsClient, error)
+}
+
+type adminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminClient(cc grpc.ClientConnInterface) AdminClient {
+	return &adminClient{cc}
+}
+
+func (c *adminClient) Logging(ctx context.Context, in *LogFilter, opts ...grpc.CallOption) (Admin_LoggingClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Admin_ServiceDesc.Streams[0], "/main.Admin/Logging", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &adminLoggingClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Admin_LoggingClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type adminLoggingClient struct {
+	grpc.ClientStream
+}
+
+func (x *adminLoggingClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *adminClient) Statistics(ctx context.Context, in *StatInterval, opts ...grpc.CallOption) (Admin_StatisticsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Admin_ServiceDesc.Streams[1], "/main.Admin/Statistics", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &adminStatisticsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Admin_StatisticsClient interface {
+	Recv() (*Stat, error)
+	grpc.ClientStream
+}
+
+type adminStatisticsClient struct {
+	grpc.ClientStream
+}
+
+func (x *adminStatisticsClient) Recv() (*Stat, error) {
+	m := new(Stat)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *adminClient) UpdateACL(ctx context.Context, in *AclDocument, opts ...grpc.CallOption) (*AclChange, error) {
+	out := new(AclChange)
+	err := c.cc.Invoke(ctx, "/main.Admin/UpdateACL", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Control(ctx context.Context, opts ...grpc.CallOption) (Admin_ControlClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Admin_ServiceDesc.Streams[2], "/main.Admin/Control", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &adminControlClient{stream}
+	return x, nil
+}
+
+type Admin_ControlClient interface {
+	Send(*ControlRequest) error
+	Recv() (*ControlMessage, error)
+	grpc.ClientStream
+}
+
+type adminControlClient struct {
+	grpc.ClientStream
+}
+
+func (x *adminControlClient) Send(m *ControlRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *adminControlClient) Recv() (*ControlMessage, error) {
+	m := new(ControlMessage)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *adminClient) Alerts(ctx context.Context, in *Nothing, opts ...grpc.CallOption) (Admin_AlertsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Admin_ServiceDesc.Streams[3], "/main.Admin/Alerts", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &adminAlertsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Admin_AlertsClient interface {
+	Recv() (*Alert, error)
+	grpc.ClientStream
+}
+
+type adminAlertsClient struct {
+	grpc.ClientStream
+}
+
+func (x *adminAlertsClient) Recv() (*Alert, error) {
+	m := new(Alert)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// AdminServer is the server API for Admin service.
+// All implementations must embed UnimplementedAdminServer
+// for forward compatibility
+type AdminServer interface {
+	Logging(*LogFilter, Admin_LoggingServer) error
+	Statistics(*StatInterval, Admin_StatisticsServer) error
+	UpdateACL(context.Context, *AclDocument) (*AclChange, error)
+	Control(Admin_ControlServer) error
+	Alerts(*Nothing, Admin_AlertsServer) error
+	mustEmbedUnimplementedAdminServer()
+}
+
+// UnimplementedAdminServer must be embedded to have forward compatible implementations.
+type UnimplementedAdminServer struct {
+}
+
+func (UnimplementedAdminServer) Logging(*LogFilter, Admin_LoggingServer) error {
+	return status.Errorf(codes.Unimplemented, "method Logging not implemented")
+}
+func (UnimplementedAdminServer) Statistics(*StatInterval, Admin_StatisticsServer) error {
+	return status.Errorf(codes.Unimplemented, "method Statistics not implemented")
+}
+func (UnimplementedAdminServer) UpdateACL(context.Context, *AclDocument) (*AclChange, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateACL not implemented")
+}
+func (UnimplementedAdminServer) Control(Admin_ControlServer) error {
+	return status.Errorf(codes.Unimplemented, "method Control not implemented")
+}
+func (UnimplementedAdminServer) Alerts(*Nothing, Admin_AlertsServer) error {
+	return status.Errorf(codes.Unimplemented, "method Alerts not implemented")
+}
+func (UnimplementedAdminServer) mustEmbedUnimplementedAdminServer() {}
+
+// UnsafeAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServer will
+// result in compilation errors.
+type UnsafeAdminServer interface {
+	mustEmbedUnimplementedAdminServer()
+}
+
+func RegisterAdminServer(s grpc.ServiceRegistrar, srv AdminServer) {
+	s.RegisterService(&Admin_ServiceDesc, srv)
+}
+
+func _Admin_Logging_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(LogFilter)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AdminServer).Logging(m, &adminLoggingServer{stream})
+}
+
+type Admin_LoggingServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type adminLoggingServer struct {
+	grpc.ServerStream
+}
+
+func (x *adminLoggingServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Admin_Statistics_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StatInterval)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AdminServer).Statistics(m, &adminStatisticsServer{stream})
+}
+
+type Admin_StatisticsServer interface {
+	Send(*Stat) error
+	grpc.ServerStream
+}
+
+type adminStatisticsServer struct {
+	grpc.ServerStream
+}
+
+func (x *adminStatisticsServer) Send(m *Stat) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Admin_UpdateACL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AclDocument)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).UpdateACL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/main.Admin/UpdateACL",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).UpdateACL(ctx, req.(*AclDocument))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_Control_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AdminServer).Control(&adminControlServer{stream})
+}
+
+type Admin_ControlServer interface {
+	Send(*ControlMessage) error
+	Recv() (*ControlRequest, error)
+	grpc.ServerStream
+}
+
+type adminControlServer struct {
+	grpc.ServerStream
+}
+
+func (x *adminControlServer) Send(m *ControlMessage) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *adminControlServer) Recv() (*ControlRequest, error) {
+	m := new(ControlRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Admin_Alerts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Nothing)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AdminServer).Alerts(m, &adminAlertsServer{stream})
+}
+
+type Admin_AlertsServer interface {
+	Send(*Alert) error
+	grpc.ServerStream
+}
+
+type adminAlertsServer struct {
+	grpc.ServerStream
+}
+
+func (x *adminAlertsServer) Send(m *Alert) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Admin_ServiceDesc is the grpc.ServiceDesc for Admin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Admin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "main.Admin",
+	HandlerType: (*AdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "UpdateACL",
+			Handler:    _Admin_UpdateACL_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Logging",
+			Handler:       _Admin_Logging_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Statistics",
+			Handler:       _Admin_Statistics_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Control",
+			Handler:       _Admin_Control_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Alerts",
+			Handler:       _Admin_Alerts_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "service.proto",
+}
+
+// BizClient is the client API for Biz service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BizClient interface {
+	Check(ctx context.Context, in *CounterKey, opts ...grpc.CallOption) (*Counter, error)
+	Add(ctx context.Context, in *CounterDelta, opts ...grpc.CallOption) (*Counter, error)
+	Test(ctx context.Context, in *CounterBatch, opts ...grpc.CallOption) (*BatchResult, error)
+}
+
+type bizClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBizClient(cc grpc.ClientConnInterface) BizClient {
+	return &bizClient{cc}
+}
+
+func (c *bizClient) Check(ctx context.Context, in *CounterKey, opts ...grpc.CallOption) (*Counter, error) {
+	out := new(Counter)
+	err := c.cc.Invoke(ctx, "/main.Biz/Check", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bizClient) Add(ctx context.Context, in *CounterDelta, opts ...grpc.CallOption) (*Counter, error) {
+	out := new(Counter)
+	err := c.cc.Invoke(ctx, "/main.Biz/Add", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bizClient) Test(ctx context.Context, in *CounterBatch, opts ...grpc.CallOption) (*BatchResult, error) {
+	out := new(BatchResult)
+	err := c.cc.Invoke(ctx, "/main.Biz/Test", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BizServer is the server API for Biz service.
+// All implementations must embed UnimplementedBizServer
+// for forward compatibility
+type BizServer interface {
+	Check(context.Context, *CounterKey) (*Counter, error)
+	Add(context.Context, *CounterDelta) (*Counter, error)
+	Test(context.Context, *CounterBatch) (*BatchResult, error)
+	mustEmbedUnimplementedBizServer()
+}
+
+// UnimplementedBizServer must be embedded to have forward compatible implementations.
+type UnimplementedBizServer struct {
+}
+
+func (UnimplementedBizServer) Check(context.Context, *CounterKey) (*Counter, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Check not implemented")
+}
+func (UnimplementedBizServer) Add(context.Context, *CounterDelta) (*Counter, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Add not implemented")
+}
+func (UnimplementedBizServer) Test(context.Context, *CounterBatch) (*BatchResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Test not implemented")
+}
+func (UnimplementedBizServer) mustEmbedUnimplementedBizServer() {}
+
+// UnsafeBizServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BizServer will
+// result in compilation errors.
+type UnsafeBizServer interface {
+	mustEmbedUnimplementedBizServer()
+}
+
+func RegisterBizServer(s grpc.ServiceRegistrar, srv BizServer) {
+	s.RegisterService(&Biz_ServiceDesc, srv)
+}
+
+func _Biz_Check_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CounterKey)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BizServer).Check(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/main.Biz/Check",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BizServer).Check(ctx, req.(*CounterKey))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Biz_Add_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CounterDelta)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BizServer).Add(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/main.Biz/Add",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BizServer).Add(ctx, req.(*CounterDelta))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Biz_Test_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CounterBatch)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BizServer).Test(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/main.Biz/Test",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BizServer).Test(ctx, req.(*CounterBatch))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Biz_ServiceDesc is the grpc.ServiceDesc for Biz service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Biz_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "main.Biz",
+	HandlerType: (*BizServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Check",
+			Handler:    _Biz_Check_Handler,
+		},
+		{
+			MethodName: "Add",
+			Handler:    _Biz_Add_Handler,
+		},
+		{
+			MethodName: "Test",
+			Handler:    _Biz_Test_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "service.proto",
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"net"
+	"strings"
+	"time"
+)
+
+// authError is a string error, so that sentinel errors can be constants.
+type authError string
+
+func (e authError) Error() string { return string(e) }
+
+// errNoCredentials tells the middleware to try the next authenticator.
+const errNoCredentials = authError("no credentials")
+
+// Authenticator finds out which consumer is making the call.
+type Authenticator interface {
+	Authenticate(ctx context.Context) (string, error)
+}
+
+// MetadataAuthenticator trusts the "consumer" metadata key as is, so any
+// client can claim to be any consumer. It is insecure and only fit for tests
+// and in-process tools, see LocalMetadataAuthenticator for the default.
+type MetadataAuthenticator struct{}
+
+func (MetadataAuthenticator) Authenticate(ctx context.Context) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	consumer := strings.Join(md.Get("consumer"), "")
+	if consumer == "" {
+		return "", errNoCredentials
+	}
+
+	return consumer, nil
+}
+
+// LocalMetadataAuthenticator trusts the "consumer" metadata key only from
+// clients on the loopback interface, remote clients have to use a token or a
+// certificate. It is what a server uses unless told otherwise.
+type LocalMetadataAuthenticator struct{}
+
+func (LocalMetadataAuthenticator) Authenticate(ctx context.Context) (string, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "", errNoCredentials
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return "", errNoCredentials
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return "", errNoCredentials
+	}
+
+	return MetadataAuthenticator{}.Authenticate(ctx)
+}
+
+type hmacTokenPayload struct {
+	Consumer string `json:"sub"`
+	Expires  int64  `json:"exp"`
+}
+
+// HMACTokenAuthenticator accepts "authorization: Bearer <token>" metadata
+// with tokens made by NewHMACToken.
+type HMACTokenAuthenticator struct {
+	Key []byte
+	Now func() time.Time
+}
+
+func NewHMACTokenAuthenticator(key []byte) *HMACTokenAuthenticator {
+	return &HMACTokenAuthenticator{Key: key, Now: time.Now}
+}
+
+// NewHMACToken issues a token for consumer that expires after ttl.
+func NewHMACToken(key []byte, consumer string, ttl time.Duration) (string, error) {
+	payload, err := json.Marshal(hmacTokenPayload{
+		Consumer: consumer,
+		Expires:  time.Now().Add(ttl).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(hmacSign(key, encoded)), nil
+}
+
+func hmacSign(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
+
+func (a *HMACTokenAuthenticator) Authenticate(ctx context.Context) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	header := strings.Join(md.Get("authorization"), "")
+	if header == "" {
+		return "", errNoCredentials
+	}
+
+	token := strings.TrimPrefix(header, "Bearer ")
+	if token == header {
+		return "", fmt.Errorf("authorization is not a bearer token")
+	}
+
+	dot := strings.IndexByte(token, '.')
+	if dot < 0 {
+		return "", fmt.Errorf("malformed token")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(token[dot+1:])
+	if err != nil || !hmac.Equal(signature, hmacSign(a.Key, token[:dot])) {
+		return "", fmt.Errorf("bad token signature")
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token[:dot])
+	if err != nil {
+		return "", fmt.Errorf("malformed token")
+	}
+
+	payload := hmacTokenPayload{}
+	if err := json.Unmarshal(raw, &payload); err != nil || payload.Consumer == "" {
+		return "", fmt.Errorf("malformed token")
+	}
+
+	if a.Now().Unix() >= payload.Expires {
+		return "", fmt.Errorf("token expired")
+	}
+
+	return payload.Consumer, nil
+}
+
+// TLSAuthenticator takes the consumer from the common name of a verified
+// client certificate. Consumers maps common names to consumers, when it is
+// nil the common name is the consumer.
+type TLSAuthenticator struct {
+	Consumers map[string]string
+}
+
+func (a *TLSAuthenticator) Authenticate(ctx context.Context) (string, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", errNoCredentials
+	}
+
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return "", errNoCredentials
+	}
+
+	cn := info.State.VerifiedChains[0][0].Subject.CommonName
+	if a.Consumers == nil {
+		return cn, nil
+	}
+
+	consumer, ok := a.Consumers[cn]
+	if !ok {
+		return "", fmt.Errorf("no consumer for certificate %q", cn)
+	}
+
+	return consumer, nil
+}
+
+// authenticate asks every authenticator in turn, the first one which finds
+// credentials in the call decides.
+func authenticate(ctx context.Context, authenticators []Authenticator) (string, error) {
+	for _, a := range authenticators {
+		consumer, err := a.Authenticate(ctx)
+		if err == errNoCredentials {
+			continue
+		}
+
+		return consumer, err
+	}
+
+	return "", errNoCredentials
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func getTokenCtx(token string) context.Context {
+	return metadata.NewOutgoingContext(context.Background(), metadata.Pairs(
+		"authorization", "Bearer "+token,
+	))
+}
+
+func TestHMACToken(t *testing.T) {
+	key := []byte("secret")
+	auth := NewHMACTokenAuthenticator(key)
+
+	valid, _ := NewHMACToken(key, "biz_user", time.Minute)
+	expired, _ := NewHMACToken(key, "biz_user", -time.Minute)
+	foreign, _ := NewHMACToken([]byte("other secret"), "biz_user", time.Minute)
+
+	consumer, err := auth.Authenticate(metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		"authorization", "Bearer "+valid,
+	)))
+	if err != nil || consumer != "biz_user" {
+		t.Fatalf("expected biz_user, got %q, %v", consumer, err)
+	}
+
+	for idx, header := range []string{
+		"Bearer " + expired,
+		"Bearer " + foreign,
+		"Bearer " + valid[:len(valid)-2],
+		"Bearer garbage",
+		"Basic " + valid,
+	} {
+		_, err := auth.Authenticate(metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+			"authorization", header,
+		)))
+		if err == nil || err == errNoCredentials {
+			t.Fatalf("[%d] expected token to be rejected, got %v", idx, err)
+		}
+	}
+
+	if _, err := auth.Authenticate(context.Background()); err != errNoCredentials {
+		t.Fatalf("expected no credentials, got %v", err)
+	}
+}
+
+func TestNoAuthenticators(t *testing.T) {
+	if err := StartMyMicroservice(context.Background(), listenAddr, ACLData, WithAuthenticators()); err == nil {
+		t.Fatalf("expected a server without authenticators to be rejected")
+	}
+}
+
+func TestLocalMetadataAuth(t *testing.T) {
+	md := metadata.Pairs("consumer", "biz_user")
+
+	for _, tc := range []struct {
+		Addr     string
+		Consumer string
+	}{
+		{"127.0.0.1:5000", "biz_user"},
+		{"[::1]:5000", "biz_user"},
+		{"10.0.0.1:5000", ""},
+		{"[2001:db8::1]:5000", ""},
+	} {
+		addr, _ := net.ResolveTCPAddr("tcp", tc.Addr)
+		ctx := peer.NewContext(metadata.NewIncomingContext(context.Background(), md), &peer.Peer{Addr: addr})
+		consumer, err := LocalMetadataAuthenticator{}.Authenticate(ctx)
+		if consumer != tc.Consumer || (tc.Consumer == "") != (err != nil) {
+			t.Errorf("%s: expected %q, got %q, %v", tc.Addr, tc.Consumer, consumer, err)
+		}
+	}
+
+	if _, err := (LocalMetadataAuthenticator{}).Authenticate(metadata.NewIncomingContext(context.Background(), md)); err != errNoCredentials {
+		t.Fatalf("expected no credentials without a peer, got %v", err)
+	}
+}
+
+func TestTokenAuth(t *testing.T) {
+	key := []byte("secret")
+
+	ctx, finish := context.WithCancel(context.Background())
+	err := StartMyMicroservice(ctx, listenAddr, ACLData, WithAuthenticators(NewHMACTokenAuthenticator(key)))
+	if err != nil {
+		t.Fatalf("cant start server initial: %v", err)
+	}
+	wait(1)
+	defer func() {
+		finish()
+		wait(1)
+	}()
+
+	conn := getGrpcConn(t)
+	defer conn.Close()
+
+	biz := NewBizClient(conn)
+	adm := NewAdminClient(conn)
+
+	loggerToken, _ := NewHMACToken(key, "logger1", time.Minute)
+	logStream, err := adm.Logging(getTokenCtx(loggerToken), &LogFilter{})
+	if err != nil {
+		t.Fatalf("cant open log stream: %v", err)
+	}
+	wait(1)
+
+	// bare consumer metadata is no longer trusted
+	_, err = biz.Check(getConsumerCtx("biz_user"), &CounterKey{})
+	if code := grpc.Code(err); code != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated for consumer metadata, got %v", err)
+	}
+
+	expired, _ := NewHMACToken(key, "biz_user", -time.Minute)
+	_, err = biz.Check(getTokenCtx(expired), &CounterKey{})
+	if code := grpc.Code(err); code != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated for expired token, got %v", err)
+	}
+
+	token, _ := NewHMACToken(key, "biz_user", time.Minute)
+	if _, err = biz.Check(getTokenCtx(token), &CounterKey{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = biz.Test(getTokenCtx(token), &CounterBatch{})
+	if code := grpc.Code(err); code != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated, got %v", err)
+	}
+
+	expected := []*Event{
+		{Consumer: "", Method: "/main.Biz/Check", Denied: true},
+		{Consumer: "", Method: "/main.Biz/Check", Denied: true},
+		{Consumer: "biz_user", Method: "/main.Biz/Check"},
+		{Consumer: "biz_user", Method: "/main.Biz/Test", Denied: true},
+	}
+	for idx, want := range expected {
+		evt, err := logStream.Recv()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if evt.Consumer != want.Consumer || evt.Method != want.Method || evt.Denied != want.Denied {
+			t.Fatalf("[%d] expected %v, got %v", idx, want, evt)
+		}
+	}
+}
+
+type testPKI struct {
+	ca     *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	pool   *x509.CertPool
+	serial int64
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("cant generate key: %v", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("cant create ca: %v", err)
+	}
+	ca, _ := x509.ParseCertificate(der)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	return &testPKI{ca: ca, caKey: key, pool: pool, serial: 1}
+}
+
+func (p *testPKI) issue(t *testing.T, cn string, usage x509.ExtKeyUsage) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("cant generate key: %v", err)
+	}
+
+	p.serial++
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(p.serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, p.ca, &key.PublicKey, p.caKey)
+	if err != nil {
+		t.Fatalf("cant create certificate: %v", err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestTLSAuth(t *testing.T) {
+	pki := newTestPKI(t)
+
+	serverCreds := credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{pki.issue(t, "server", x509.ExtKeyUsageServerAuth)},
+		ClientCAs:    pki.pool,
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+	})
+
+	ctx, finish := context.WithCancel(context.Background())
+	err := StartMyMicroservice(ctx, listenAddr, ACLData,
+		WithServerOptions(grpc.Creds(serverCreds)),
+		WithAuthenticators(&TLSAuthenticator{Consumers: map[string]string{
+			"billing.internal": "biz_user",
+		}}),
+	)
+	if err != nil {
+		t.Fatalf("cant start server initial: %v", err)
+	}
+	wait(1)
+	defer func() {
+		finish()
+		wait(1)
+	}()
+
+	dial := func(certs ...tls.Certificate) BizClient {
+		conn, err := grpc.Dial(listenAddr, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+			Certificates: certs,
+			RootCAs:      pki.pool,
+		})))
+		if err != nil {
+			t.Fatalf("cant connect to grpc: %v", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+
+		return NewBizClient(conn)
+	}
+
+	biz := dial(pki.issue(t, "billing.internal", x509.ExtKeyUsageClientAuth))
+	if _, err := biz.Check(context.Background(), &CounterKey{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = biz.Test(context.Background(), &CounterBatch{})
+	if code := grpc.Code(err); code != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated, got %v", err)
+	}
+
+	// the consumer header is ignored, only the certificate counts
+	_, err = biz.Test(getConsumerCtx("biz_admin"), &CounterBatch{})
+	if code := grpc.Code(err); code != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated, got %v", err)
+	}
+
+	for idx, biz := range []BizClient{
+		dial(pki.issue(t, "stranger", x509.ExtKeyUsageClientAuth)),
+		dial(),
+	} {
+		_, err = biz.Check(context.Background(), &CounterKey{})
+		if code := grpc.Code(err); code != codes.Unauthenticated {
+			t.Fatalf("[%d] expected Unauthenticated, got %v", idx, err)
+		}
+	}
+}
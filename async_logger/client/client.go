@@ -0,0 +1,253 @@
+// Package client wraps the generated Biz and Admin clients of async_logger.
+// It presents the same consumer on every call, retries idempotent Biz calls
+// while the server is unavailable and keeps Logging and Statistics streams
+// going across reconnects:
+//
+//	c, err := client.Dial(ctx, "127.0.0.1:8082", client.WithConsumer("biz_user"))
+//	counter, err := c.Check(ctx, &api.CounterKey{Name: "visits"})
+package client
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"coursera/hw7_microservice/api"
+)
+
+// Credentials tell the server who is calling: a bearer token when set,
+// the consumer name otherwise.
+type Credentials struct {
+	Consumer string
+	Token    string
+}
+
+// outgoing adds the credentials to ctx unless the caller has already put
+// some there for this one call.
+func (c Credentials) outgoing(ctx context.Context) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	if len(md.Get("authorization")) > 0 || len(md.Get("consumer")) > 0 {
+		return ctx
+	}
+
+	switch {
+	case c.Token != "":
+		return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+c.Token)
+	case c.Consumer != "":
+		return metadata.AppendToOutgoingContext(ctx, "consumer", c.Consumer)
+	}
+
+	return ctx
+}
+
+// UnaryInterceptor adds the credentials to unary calls, for use with the
+// generated clients directly.
+func (c Credentials) UnaryInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(c.outgoing(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamInterceptor adds the credentials to streaming calls.
+func (c Credentials) StreamInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(c.outgoing(ctx), desc, cc, method, opts...)
+	}
+}
+
+// Backoff spaces out retries: the n-th one waits a random time up to
+// Initial*Multiplier^n, but never more than Max. Zero Attempts retries
+// until the context ends.
+type Backoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	Attempts   int
+}
+
+// DefaultBackoff gives up after about three seconds.
+func DefaultBackoff() Backoff {
+	return Backoff{
+		Initial:    50 * time.Millisecond,
+		Max:        2 * time.Second,
+		Multiplier: 2,
+		Attempts:   6,
+	}
+}
+
+// delay is the full jitter variant, retrying clients spread out instead
+// of hitting a restarted server at the same moment.
+func (b Backoff) delay(retry int) time.Duration {
+	ceiling := float64(b.Initial)
+	for i := 0; i < retry && ceiling < float64(b.Max); i++ {
+		ceiling *= b.Multiplier
+	}
+	if ceiling > float64(b.Max) {
+		ceiling = float64(b.Max)
+	}
+	if ceiling < 1 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(ceiling)) + 1)
+}
+
+// wait sleeps before the given retry and tells whether to make it at all.
+func (b Backoff) wait(ctx context.Context, retry int) bool {
+	if b.Attempts > 0 && retry+1 >= b.Attempts {
+		return false
+	}
+
+	t := time.NewTimer(b.delay(retry))
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// retryable errors mean the call has not been handled, the server is
+// restarting or the connection is being reestablished.
+func retryable(err error) bool {
+	return status.Code(err) == codes.Unavailable
+}
+
+type options struct {
+	credentials Credentials
+	backoff     Backoff
+	transport   credentials.TransportCredentials
+	dialOptions []grpc.DialOption
+}
+
+type Option func(*options)
+
+// WithConsumer names the consumer in the "consumer" metadata. The server
+// only uses it when it authenticates by metadata, by default just for
+// clients on the same host; otherwise see WithToken and
+// WithTransportCredentials.
+func WithConsumer(consumer string) Option {
+	return func(o *options) {
+		o.credentials.Consumer = consumer
+	}
+}
+
+// WithToken calls with a bearer token, it wins over WithConsumer.
+func WithToken(token string) Option {
+	return func(o *options) {
+		o.credentials.Token = token
+	}
+}
+
+// WithBackoff replaces DefaultBackoff.
+func WithBackoff(b Backoff) Option {
+	return func(o *options) {
+		o.backoff = b
+	}
+}
+
+// WithTransportCredentials secures the connection, it is plaintext otherwise.
+func WithTransportCredentials(creds credentials.TransportCredentials) Option {
+	return func(o *options) {
+		o.transport = creds
+	}
+}
+
+// WithDialOptions passes extra options to grpc.DialContext, transport
+// credentials go through WithTransportCredentials.
+func WithDialOptions(dialOptions ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.dialOptions = append(o.dialOptions, dialOptions...)
+	}
+}
+
+// Client implements api.BizClient, Check and Test are retried, Add is not
+// as it could be counted twice. Admin streams come wrapped as LogStream and
+// StatStream, the rest of the Admin service is available through Admin.
+type Client struct {
+	conn    *grpc.ClientConn
+	biz     api.BizClient
+	admin   api.AdminClient
+	backoff Backoff
+}
+
+// Dial connects to addr lazily, the first calls wait for the connection
+// within their retries.
+func Dial(ctx context.Context, addr string, opts ...Option) (*Client, error) {
+	o := &options{backoff: DefaultBackoff()}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	dialOptions := []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(o.credentials.UnaryInterceptor()),
+		grpc.WithChainStreamInterceptor(o.credentials.StreamInterceptor()),
+	}
+	if o.transport != nil {
+		dialOptions = append(dialOptions, grpc.WithTransportCredentials(o.transport))
+	} else {
+		dialOptions = append(dialOptions, grpc.WithInsecure())
+	}
+
+	conn, err := grpc.DialContext(ctx, addr, append(dialOptions, o.dialOptions...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		conn:    conn,
+		biz:     api.NewBizClient(conn),
+		admin:   api.NewAdminClient(conn),
+		backoff: o.backoff,
+	}, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Admin is the plain Admin client, with credentials but without retries.
+func (c *Client) Admin() api.AdminClient {
+	return c.admin
+}
+
+func (c *Client) retry(ctx context.Context, call func() error) error {
+	for retry := 0; ; retry++ {
+		err := call()
+		if !retryable(err) || !c.backoff.wait(ctx, retry) {
+			return err
+		}
+	}
+}
+
+func (c *Client) Check(ctx context.Context, in *api.CounterKey, opts ...grpc.CallOption) (*api.Counter, error) {
+	var out *api.Counter
+	err := c.retry(ctx, func() (err error) {
+		out, err = c.biz.Check(ctx, in, opts...)
+		return err
+	})
+
+	return out, err
+}
+
+func (c *Client) Add(ctx context.Context, in *api.CounterDelta, opts ...grpc.CallOption) (*api.Counter, error) {
+	return c.biz.Add(ctx, in, opts...)
+}
+
+func (c *Client) Test(ctx context.Context, in *api.CounterBatch, opts ...grpc.CallOption) (*api.BatchResult, error) {
+	var out *api.BatchResult
+	err := c.retry(ctx, func() (err error) {
+		out, err = c.biz.Test(ctx, in, opts...)
+		return err
+	})
+
+	return out, err
+}
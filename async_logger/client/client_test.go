@@ -0,0 +1,329 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"coursera/hw7_microservice/api"
+)
+
+func testBackoff() Backoff {
+	return Backoff{Initial: time.Millisecond, Max: 5 * time.Millisecond, Multiplier: 2, Attempts: 4}
+}
+
+// fakeServer fails calls with the errors queued in fail and records who
+// has called. Streams are scripted per connection.
+type fakeServer struct {
+	api.UnimplementedBizServer
+	api.UnimplementedAdminServer
+
+	mux     *sync.Mutex
+	fail    []error
+	calls   []string
+	callers []string
+	filters []*api.LogFilter
+	logs    []func(srv api.Admin_LoggingServer) error
+	stats   []func(srv api.Admin_StatisticsServer) error
+}
+
+func newFakeServer() *fakeServer {
+	return &fakeServer{mux: &sync.Mutex{}}
+}
+
+func (fs *fakeServer) call(ctx context.Context, method string) error {
+	fs.mux.Lock()
+	defer fs.mux.Unlock()
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	fs.calls = append(fs.calls, method)
+	fs.callers = append(fs.callers, strings.Join(md.Get("consumer"), "")+strings.Join(md.Get("authorization"), ""))
+
+	if len(fs.fail) == 0 {
+		return nil
+	}
+	err := fs.fail[0]
+	fs.fail = fs.fail[1:]
+
+	return err
+}
+
+func (fs *fakeServer) Check(ctx context.Context, key *api.CounterKey) (*api.Counter, error) {
+	if err := fs.call(ctx, "Check"); err != nil {
+		return nil, err
+	}
+	return &api.Counter{Name: key.Name, Value: 1}, nil
+}
+
+func (fs *fakeServer) Add(ctx context.Context, d *api.CounterDelta) (*api.Counter, error) {
+	if err := fs.call(ctx, "Add"); err != nil {
+		return nil, err
+	}
+	return &api.Counter{Name: d.Name, Value: 2}, nil
+}
+
+func (fs *fakeServer) Logging(f *api.LogFilter, srv api.Admin_LoggingServer) error {
+	fs.mux.Lock()
+	fs.filters = append(fs.filters, f)
+	script := fs.logs[0]
+	fs.logs = fs.logs[1:]
+	fs.mux.Unlock()
+
+	return script(srv)
+}
+
+func (fs *fakeServer) Statistics(si *api.StatInterval, srv api.Admin_StatisticsServer) error {
+	fs.mux.Lock()
+	script := fs.stats[0]
+	fs.stats = fs.stats[1:]
+	fs.mux.Unlock()
+
+	return script(srv)
+}
+
+func startFakeServer(t *testing.T, fs *fakeServer, opts ...Option) *Client {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cant listen: %v", err)
+	}
+
+	server := grpc.NewServer()
+	api.RegisterBizServer(server, fs)
+	api.RegisterAdminServer(server, fs)
+	go server.Serve(l)
+	t.Cleanup(server.Stop)
+
+	c, err := Dial(context.Background(), l.Addr().String(), append([]Option{WithBackoff(testBackoff())}, opts...)...)
+	if err != nil {
+		t.Fatalf("cant dial: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	return c
+}
+
+func TestCredentials(t *testing.T) {
+	fs := newFakeServer()
+	byConsumer := startFakeServer(t, fs, WithConsumer("biz_user"))
+	byToken := startFakeServer(t, fs, WithConsumer("biz_user"), WithToken("secret"))
+
+	ctx := context.Background()
+	byConsumer.Check(ctx, &api.CounterKey{})
+	byToken.Check(ctx, &api.CounterKey{})
+	// set for a single call it wins
+	byConsumer.Check(metadata.AppendToOutgoingContext(ctx, "consumer", "biz_admin"), &api.CounterKey{})
+
+	expected := []string{"biz_user", "Bearer secret", "biz_admin"}
+	if !reflect.DeepEqual(fs.callers, expected) {
+		t.Fatalf("callers dont match\nhave %q\nwant %q", fs.callers, expected)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	unavailable := status.Errorf(codes.Unavailable, "server is shutting down")
+	denied := status.Errorf(codes.PermissionDenied, "access denied")
+
+	for idx, tc := range []struct {
+		fail  []error
+		call  func(c *Client) error
+		calls int
+		code  codes.Code
+	}{
+		{[]error{unavailable, unavailable}, func(c *Client) error {
+			_, err := c.Check(context.Background(), &api.CounterKey{})
+			return err
+		}, 3, codes.OK},
+		{[]error{unavailable, unavailable, unavailable, unavailable, unavailable}, func(c *Client) error {
+			_, err := c.Check(context.Background(), &api.CounterKey{})
+			return err
+		}, testBackoff().Attempts, codes.Unavailable},
+		{[]error{denied}, func(c *Client) error {
+			_, err := c.Check(context.Background(), &api.CounterKey{})
+			return err
+		}, 1, codes.PermissionDenied},
+		// could have been counted already
+		{[]error{unavailable}, func(c *Client) error {
+			_, err := c.Add(context.Background(), &api.CounterDelta{})
+			return err
+		}, 1, codes.Unavailable},
+	} {
+		fs := newFakeServer()
+		fs.fail = tc.fail
+		err := tc.call(startFakeServer(t, fs))
+		if len(fs.calls) != tc.calls || status.Code(err) != tc.code {
+			t.Errorf("[%d] expected %d calls and %v, got %d and %v", idx, tc.calls, tc.code, len(fs.calls), err)
+		}
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{Initial: 10 * time.Millisecond, Max: 50 * time.Millisecond, Multiplier: 2}
+	for retry, ceiling := range []time.Duration{10, 20, 40, 50, 50} {
+		for i := 0; i < 100; i++ {
+			if d := b.delay(retry); d <= 0 || d > ceiling*time.Millisecond {
+				t.Fatalf("retry %d: delay %v out of (0, %v]", retry, d, ceiling*time.Millisecond)
+			}
+		}
+	}
+}
+
+func sendEvents(seqs ...uint64) func(srv api.Admin_LoggingServer) error {
+	return func(srv api.Admin_LoggingServer) error {
+		for _, seq := range seqs {
+			if err := srv.Send(&api.Event{Seq: seq}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func then(script func(srv api.Admin_LoggingServer) error, err error) func(srv api.Admin_LoggingServer) error {
+	return func(srv api.Admin_LoggingServer) error {
+		script(srv)
+		return err
+	}
+}
+
+func TestLogStream(t *testing.T) {
+	unavailable := status.Errorf(codes.Unavailable, "server is shutting down")
+	noJournal := status.Errorf(codes.FailedPrecondition, "event journal is not enabled")
+	gap := status.Errorf(codes.FailedPrecondition, "events 3 to 4 are missing from the journal")
+
+	for idx, tc := range []struct {
+		logs     []func(srv api.Admin_LoggingServer) error
+		seqs     []uint64
+		fromSeqs []uint64
+	}{
+		// resumed from the journal, the overlap is skipped
+		{
+			[]func(srv api.Admin_LoggingServer) error{
+				then(sendEvents(1, 2, 3), unavailable),
+				sendEvents(3, 4, 5),
+			},
+			[]uint64{1, 2, 3, 4, 5},
+			[]uint64{0, 4},
+		},
+		// no journal, the stream goes on with live events of the new server
+		{
+			[]func(srv api.Admin_LoggingServer) error{
+				then(sendEvents(1, 2), unavailable),
+				then(sendEvents(), noJournal),
+				then(sendEvents(1), unavailable),
+				sendEvents(1, 2),
+			},
+			[]uint64{1, 2, 1, 1, 2},
+			[]uint64{0, 3, 0, 0},
+		},
+		// the journal lost what was missed, the stream goes on with live
+		// events instead of failing
+		{
+			[]func(srv api.Admin_LoggingServer) error{
+				then(sendEvents(1, 2), unavailable),
+				then(sendEvents(), gap),
+				sendEvents(7, 8),
+			},
+			[]uint64{1, 2, 7, 8},
+			[]uint64{0, 3, 0},
+		},
+	} {
+		fs := newFakeServer()
+		fs.logs = tc.logs
+		c := startFakeServer(t, fs)
+
+		stream, err := c.Logging(context.Background(), &api.LogFilter{WithResults: true})
+		if err != nil {
+			t.Fatalf("[%d] cant open log stream: %v", idx, err)
+		}
+
+		var seqs []uint64
+		for {
+			e, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("[%d] unexpected error: %v", idx, err)
+			}
+			seqs = append(seqs, e.Seq)
+		}
+
+		var fromSeqs []uint64
+		for _, f := range fs.filters {
+			if !f.WithResults {
+				t.Fatalf("[%d] filter is lost on reconnect: %v", idx, f)
+			}
+			fromSeqs = append(fromSeqs, f.FromSeq)
+		}
+
+		if !reflect.DeepEqual(seqs, tc.seqs) || !reflect.DeepEqual(fromSeqs, tc.fromSeqs) {
+			t.Errorf("[%d] expected events %v asked from %v, got %v from %v", idx, tc.seqs, tc.fromSeqs, seqs, fromSeqs)
+		}
+	}
+}
+
+func TestStatStream(t *testing.T) {
+	unavailable := status.Errorf(codes.Unavailable, "server is shutting down")
+	send := func(err error, stats ...*api.Stat) func(srv api.Admin_StatisticsServer) error {
+		return func(srv api.Admin_StatisticsServer) error {
+			for _, stat := range stats {
+				if err := srv.Send(stat); err != nil {
+					return err
+				}
+			}
+			return err
+		}
+	}
+
+	for idx, tc := range []struct {
+		mode     api.StatInterval_Mode
+		expected []map[string]uint64
+	}{
+		// a new stream counts from zero again
+		{api.StatInterval_CUMULATIVE, []map[string]uint64{{"Check": 1}, {"Check": 3}, {"Check": 5, "Add": 1}}},
+		{api.StatInterval_INTERVAL, []map[string]uint64{{"Check": 1}, {"Check": 3}, {"Check": 2, "Add": 1}}},
+	} {
+		fs := newFakeServer()
+		fs.stats = []func(srv api.Admin_StatisticsServer) error{
+			send(unavailable, &api.Stat{ByMethod: map[string]uint64{"Check": 1}}, &api.Stat{ByMethod: map[string]uint64{"Check": 3}, Dropped: 1}),
+			send(nil, &api.Stat{ByMethod: map[string]uint64{"Check": 2, "Add": 1}}),
+		}
+		c := startFakeServer(t, fs)
+
+		stream, err := c.Statistics(context.Background(), &api.StatInterval{IntervalSeconds: 1, Mode: tc.mode})
+		if err != nil {
+			t.Fatalf("[%d] cant open stat stream: %v", idx, err)
+		}
+
+		var byMethod []map[string]uint64
+		var dropped uint64
+		for {
+			stat, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("[%d] unexpected error: %v", idx, err)
+			}
+			byMethod = append(byMethod, stat.ByMethod)
+			dropped = stat.Dropped
+		}
+
+		if !reflect.DeepEqual(byMethod, tc.expected) {
+			t.Errorf("[%d] stats dont match\nhave %v\nwant %v", idx, byMethod, tc.expected)
+		}
+		if tc.mode == api.StatInterval_CUMULATIVE && dropped != 1 {
+			t.Errorf("[%d] expected dropped to be carried over, got %d", idx, dropped)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package client
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"coursera/hw7_microservice/api"
+	"coursera/hw7_microservice/internal/counts"
+)
+
+// LogStream is Admin.Logging that reconnects when the server goes away.
+// With the event journal enabled on the server it resumes after the last
+// event received, so nothing is lost or seen twice. Without the journal
+// the events published while disconnected are lost.
+type LogStream struct {
+	c      *Client
+	ctx    context.Context
+	filter *api.LogFilter
+	stream api.Admin_LoggingClient
+
+	lastSeq uint64
+	live    bool // the server has no journal to resume from
+	retries int  // reconnects since the last event
+}
+
+// Logging subscribes to events matching filter, see LogStream.
+func (c *Client) Logging(ctx context.Context, filter *api.LogFilter) (*LogStream, error) {
+	s := &LogStream{c: c, ctx: ctx, filter: filter}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *LogStream) open() error {
+	filter := s.filter
+	if s.lastSeq > 0 && !s.live {
+		filter = proto.Clone(s.filter).(*api.LogFilter)
+		filter.FromSeq, filter.FromTimestamp = s.lastSeq+1, 0
+	}
+
+	return s.c.retry(s.ctx, func() error {
+		stream, err := s.c.admin.Logging(s.ctx, filter)
+		if err == nil {
+			// the answer of the server, errors included, only comes
+			// with the first Recv
+			s.stream = stream
+		}
+		return err
+	})
+}
+
+func (s *LogStream) Recv() (*api.Event, error) {
+	for {
+		e, err := s.stream.Recv()
+		if err == nil {
+			s.retries = 0
+			// resuming may overlap with what has been received already
+			if e.Seq != 0 && e.Seq <= s.lastSeq {
+				continue
+			}
+			if e.Seq != 0 {
+				s.lastSeq = e.Seq
+			}
+			return e, nil
+		}
+
+		switch {
+		case retryable(err):
+		case status.Code(err) == codes.FailedPrecondition && s.lastSeq > 0 && !s.live:
+			// no journal or a gap in it, from now on only live events are
+			// asked for
+			s.live = true
+		default:
+			return nil, err
+		}
+
+		if !s.c.backoff.wait(s.ctx, s.retries) {
+			return nil, err
+		}
+		s.retries++
+		if s.live {
+			// numbering restarts with the server, nothing to skip
+			s.lastSeq = 0
+		}
+		if err := s.open(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// StatStream is Admin.Statistics that reconnects when the server goes away.
+// A new stream counts from scratch, so in CUMULATIVE mode the totals of the
+// previous streams are added to it. Calls made between the last statistics
+// received and the reconnect are not counted.
+type StatStream struct {
+	c      *Client
+	ctx    context.Context
+	si     *api.StatInterval
+	stream api.Admin_StatisticsClient
+
+	base    *api.Stat // totals of the streams before this one
+	last    *api.Stat // the latest from this one
+	retries int
+}
+
+// Statistics subscribes to statistics, see StatStream.
+func (c *Client) Statistics(ctx context.Context, si *api.StatInterval) (*StatStream, error) {
+	s := &StatStream{c: c, ctx: ctx, si: si}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *StatStream) open() error {
+	return s.c.retry(s.ctx, func() error {
+		stream, err := s.c.admin.Statistics(s.ctx, s.si)
+		if err == nil {
+			s.stream = stream
+		}
+		return err
+	})
+}
+
+func (s *StatStream) Recv() (*api.Stat, error) {
+	for {
+		stat, err := s.stream.Recv()
+		if err == nil {
+			s.retries = 0
+			if s.si.Mode != api.StatInterval_CUMULATIVE {
+				return stat, nil
+			}
+			s.last = stat
+			return mergeStats(s.base, stat), nil
+		}
+
+		if !retryable(err) || !s.c.backoff.wait(s.ctx, s.retries) {
+			return nil, err
+		}
+		s.retries++
+		if s.last != nil {
+			s.base, s.last = mergeStats(s.base, s.last), nil
+		}
+		if err := s.open(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// mergeStats adds up the counters of a and b. Percentiles can not be added,
+// those of b are taken where it has any.
+func mergeStats(a, b *api.Stat) *api.Stat {
+	if a == nil {
+		return b
+	}
+
+	res := &api.Stat{
+		Timestamp:       b.Timestamp,
+		ByMethod:        counts.Add(counts.Add(nil, a.ByMethod), b.ByMethod),
+		ByConsumer:      counts.Add(counts.Add(nil, a.ByConsumer), b.ByConsumer),
+		ByCode:          counts.Add(counts.Add(nil, a.ByCode), b.ByCode),
+		ErrorsByMethod:  counts.Add(counts.Add(nil, a.ErrorsByMethod), b.ErrorsByMethod),
+		Dropped:         a.Dropped + b.Dropped,
+		LatencyByMethod: map[string]*api.Latency{},
+	}
+	for method, l := range a.LatencyByMethod {
+		res.LatencyByMethod[method] = l
+	}
+	for method, l := range b.LatencyByMethod {
+		res.LatencyByMethod[method] = l
+	}
+
+	return res
+}
@@ -0,0 +1,171 @@
+// adminctl talks to the Admin service of async_logger:
+//
+//	adminctl -consumer logger tail -results -method '/main.Biz/*'
+//	adminctl -consumer stat stats -interval 2 -mode cumulative
+//	adminctl -ca ca.pem -cert stat.pem -key stat.key stats
+//
+// Streams are resumed when the server restarts, see the client package.
+// It exits with 3 when the server does not accept the credentials and
+// with 4 when the ACL denies the call.
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+
+	"coursera/hw7_microservice/client"
+)
+
+const (
+	exitOK = iota
+	exitError
+	exitUsage
+	exitUnauthenticated
+	exitPermissionDenied
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// command runs against the server once its flags have been parsed.
+type command func(ctx context.Context, c *client.Client, stdout io.Writer) error
+
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) int {
+	global := flag.NewFlagSet("adminctl", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	addr := global.String("addr", "127.0.0.1:8082", "server address")
+	consumer := global.String("consumer", "", "consumer to act as")
+	token := global.String("token", "", "bearer token, sent instead of -consumer when set")
+	cert := global.String("cert", "", "client certificate file, for servers that authenticate by certificate")
+	key := global.String("key", "", "private key file of -cert")
+	ca := global.String("ca", "", "CA certificate file to verify the server with, enables TLS")
+	timeout := global.Duration("dial-timeout", 5*time.Second, "how long to wait for the server")
+	global.Usage = func() {
+		fmt.Fprintf(stderr, "usage: adminctl [flags] tail|stats [command flags]\n")
+		global.PrintDefaults()
+	}
+	if err := global.Parse(args); err != nil {
+		return exitUsage
+	}
+	if global.NArg() == 0 {
+		global.Usage()
+		return exitUsage
+	}
+
+	var parse func([]string, io.Writer) (command, error)
+	switch global.Arg(0) {
+	case "tail":
+		parse = parseTail
+	case "stats":
+		parse = parseStats
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n", global.Arg(0))
+		global.Usage()
+		return exitUsage
+	}
+	command, err := parse(global.Args()[1:], stderr)
+	if err != nil {
+		return exitUsage
+	}
+	if (*cert == "") != (*key == "") {
+		fmt.Fprintf(stderr, "-cert and -key go together\n")
+		return exitUsage
+	}
+
+	opts := []client.Option{
+		client.WithConsumer(*consumer),
+		client.WithToken(*token),
+		client.WithDialOptions(grpc.WithBlock()),
+	}
+	if *cert != "" || *ca != "" {
+		creds, err := loadTLS(*cert, *key, *ca)
+		if err != nil {
+			fmt.Fprintf(stderr, "%v\n", err)
+			return exitError
+		}
+		opts = append(opts, client.WithTransportCredentials(creds))
+	}
+
+	dialCtx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	c, err := client.Dial(dialCtx, *addr, opts...)
+	if err != nil {
+		fmt.Fprintf(stderr, "cant connect to %s: %v\n", *addr, err)
+		return exitError
+	}
+	defer c.Close()
+
+	err = command(ctx, c, stdout)
+	if err == nil || ctx.Err() != nil {
+		// the server has closed the stream or we were interrupted
+		return exitOK
+	}
+
+	switch status.Code(err) {
+	case codes.Unauthenticated:
+		fmt.Fprintf(stderr, "not authenticated: %s\n", status.Convert(err).Message())
+		return exitUnauthenticated
+	case codes.PermissionDenied:
+		fmt.Fprintf(stderr, "permission denied: %s\n", status.Convert(err).Message())
+		return exitPermissionDenied
+	}
+	fmt.Fprintf(stderr, "%v\n", err)
+
+	return exitError
+}
+
+// loadTLS makes transport credentials presenting the certificate in
+// certFile, if any, and trusting the CA in caFile, or the system roots
+// without one.
+func loadTLS(certFile string, keyFile string, caFile string) (credentials.TransportCredentials, error) {
+	config := &tls.Config{}
+
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cant load client certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if caFile != "" {
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("cant read CA certificate: %v", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates in %s", caFile)
+		}
+	}
+
+	return credentials.NewTLS(config), nil
+}
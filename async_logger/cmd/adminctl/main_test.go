@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"coursera/hw7_microservice/api"
+)
+
+// fakeAdmin lets "logger" and "stat" in, knows "biz_user" but denies it
+// and does not know anybody else.
+type fakeAdmin struct {
+	api.UnimplementedAdminServer
+
+	filter *api.LogFilter
+	events []*api.Event
+	stat   *api.Stat
+	peerCN string // of the client certificate, if any
+}
+
+func (fa *fakeAdmin) check(ctx context.Context, allowed string) error {
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.PeerCertificates) > 0 {
+			fa.peerCN = info.State.PeerCertificates[0].Subject.CommonName
+		}
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	switch consumer := strings.Join(md.Get("consumer"), ""); consumer {
+	case allowed:
+		return nil
+	case "biz_user":
+		return status.Errorf(codes.PermissionDenied, "access denied")
+	default:
+		return status.Errorf(codes.Unauthenticated, "unknown consumer %q", consumer)
+	}
+}
+
+func (fa *fakeAdmin) Logging(f *api.LogFilter, srv api.Admin_LoggingServer) error {
+	if err := fa.check(srv.Context(), "logger"); err != nil {
+		return err
+	}
+
+	fa.filter = f
+	for _, e := range fa.events {
+		if err := srv.Send(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (fa *fakeAdmin) Statistics(si *api.StatInterval, srv api.Admin_StatisticsServer) error {
+	if err := fa.check(srv.Context(), "stat"); err != nil {
+		return err
+	}
+
+	return srv.Send(fa.stat)
+}
+
+func startFakeAdmin(t *testing.T, fa *fakeAdmin, opts ...grpc.ServerOption) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cant listen: %v", err)
+	}
+
+	server := grpc.NewServer(opts...)
+	api.RegisterAdminServer(server, fa)
+	go server.Serve(l)
+	t.Cleanup(server.Stop)
+
+	return l.Addr().String()
+}
+
+func runCmd(t *testing.T, args ...string) (int, string, string) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	code := run(context.Background(), args, stdout, stderr)
+
+	return code, stdout.String(), stderr.String()
+}
+
+func testEvents() []*api.Event {
+	return []*api.Event{
+		{Timestamp: 1767225600, Seq: 7, Consumer: "biz_user", Host: "127.0.0.1:5000", Method: "/main.Biz/Test",
+			Denied: true, Reason: "no rule of consumer biz_user matches /main.Biz/Test", TraceId: "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{Timestamp: 1767225601, Host: "127.0.0.1:5001", Method: "/main.Biz/Test",
+			Result: &api.CallResult{Code: "Unauthenticated", LatencyMicros: 1500}, Dropped: 2},
+	}
+}
+
+func TestTail(t *testing.T) {
+	fa := &fakeAdmin{events: testEvents()}
+	addr := startFakeAdmin(t, fa)
+
+	code, stdout, stderr := runCmd(t, "-addr", addr, "-consumer", "logger",
+		"tail", "-of", "biz_user", "-of", "unknown", "-method", "/main.Biz/*", "-outcome", "denied", "-results")
+	if code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr)
+	}
+
+	expectedFilter := &api.LogFilter{
+		Consumers:   []string{"biz_user", "unknown"},
+		Methods:     []string{"/main.Biz/*"},
+		Outcome:     api.LogFilter_DENIED,
+		WithResults: true,
+	}
+	if !proto.Equal(fa.filter, expectedFilter) {
+		t.Fatalf("filter dont match\nhave %v\nwant %v", fa.filter, expectedFilter)
+	}
+
+	expected := "2026-01-01T00:00:00Z #7 biz_user 127.0.0.1:5000 /main.Biz/Test denied: no rule of consumer biz_user matches /main.Biz/Test trace=4bf92f3577b34da6a3ce929d0e0e4736\n" +
+		"2026-01-01T00:00:01Z - 127.0.0.1:5001 /main.Biz/Test -> Unauthenticated in 1.5ms (2 events dropped before)\n"
+	if stdout != expected {
+		t.Fatalf("output dont match\nhave %s\nwant %s", stdout, expected)
+	}
+}
+
+func TestTailJSON(t *testing.T) {
+	events := testEvents()
+	addr := startFakeAdmin(t, &fakeAdmin{events: events})
+
+	code, stdout, stderr := runCmd(t, "-addr", addr, "-consumer", "logger", "tail", "-json")
+	if code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	if len(lines) != len(events) {
+		t.Fatalf("expected %d lines, got %q", len(events), stdout)
+	}
+	for i, line := range lines {
+		e := &api.Event{}
+		if err := protojson.Unmarshal([]byte(line), e); err != nil {
+			t.Fatalf("[%d] bad json %q: %v", i, line, err)
+		}
+		if !proto.Equal(e, events[i]) {
+			t.Fatalf("[%d] event dont match\nhave %v\nwant %v", i, e, events[i])
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	addr := startFakeAdmin(t, &fakeAdmin{stat: &api.Stat{
+		Timestamp:       1767225600,
+		ByMethod:        map[string]uint64{"/main.Biz/Check": 3, "/main.Biz/Add": 5},
+		ByConsumer:      map[string]uint64{"biz_user": 8},
+		ByCode:          map[string]uint64{"OK": 7, "PermissionDenied": 1},
+		ErrorsByMethod:  map[string]uint64{"/main.Biz/Add": 1},
+		LatencyByMethod: map[string]*api.Latency{"/main.Biz/Add": {Count: 5, P50Ms: 0.1, P90Ms: 0.25, P99Ms: 1}},
+	}})
+
+	code, stdout, stderr := runCmd(t, "-addr", addr, "-consumer", "stat", "stats", "-refresh=false", "-top", "1")
+	if code != exitOK {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr)
+	}
+
+	var rows []string
+	for _, line := range strings.Split(stdout, "\n")[2:] {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			rows = append(rows, strings.Join(fields, " "))
+		}
+	}
+	expected := []string{
+		"METHOD CALLS ERRORS P50 ms P90 ms P99 ms",
+		"/main.Biz/Add 5 1 0.10 0.25 1.00",
+		"CONSUMER CALLS",
+		"biz_user 8",
+		"CODE CALLS",
+		"OK 7",
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Fatalf("output dont match\nhave %q\nwant %q", rows, expected)
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	addr := startFakeAdmin(t, &fakeAdmin{})
+
+	for idx, tc := range []struct {
+		args []string
+		code int
+		err  string
+	}{
+		{[]string{"-addr", addr, "-consumer", "nobody", "tail"}, exitUnauthenticated, "not authenticated"},
+		{[]string{"-addr", addr, "tail"}, exitUnauthenticated, "not authenticated"},
+		{[]string{"-addr", addr, "-consumer", "biz_user", "stats"}, exitPermissionDenied, "permission denied"},
+		{[]string{"-addr", addr, "-consumer", "stat", "stats", "-mode", "daily"}, exitUsage, "unknown mode"},
+		{[]string{"-addr", addr, "tail", "-outcome", "maybe"}, exitUsage, "unknown outcome"},
+		{[]string{"-addr", addr, "top"}, exitUsage, "unknown command"},
+		{[]string{"-addr", addr, "-cert", "client.pem", "tail"}, exitUsage, "-cert and -key"},
+		{[]string{"-addr", addr, "-ca", "missing.pem", "tail"}, exitError, "cant read CA certificate"},
+		{[]string{}, exitUsage, "usage"},
+	} {
+		code, _, stderr := runCmd(t, tc.args...)
+		if code != tc.code || !strings.Contains(stderr, tc.err) {
+			t.Errorf("[%d] expected exit code %d with %q, got %d: %s", idx, tc.code, tc.err, code, stderr)
+		}
+	}
+}
+
+// writeTestPKI writes a CA, a server certificate for 127.0.0.1 and a
+// client certificate of "logger" into dir, and returns the server side.
+func writeTestPKI(t *testing.T, dir string) tls.Certificate {
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("cant create ca: %v", err)
+	}
+	ca, _ := x509.ParseCertificate(caDER)
+
+	issue := func(serial int64, cn string, usage x509.ExtKeyUsage) ([]byte, *ecdsa.PrivateKey) {
+		key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: cn},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		}, ca, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatalf("cant create certificate: %v", err)
+		}
+		return der, key
+	}
+	writePEM := func(name string, blockType string, der []byte) {
+		data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0600); err != nil {
+			t.Fatalf("cant write %s: %v", name, err)
+		}
+	}
+
+	writePEM("ca.pem", "CERTIFICATE", caDER)
+	clientDER, clientKey := issue(2, "logger", x509.ExtKeyUsageClientAuth)
+	writePEM("client.pem", "CERTIFICATE", clientDER)
+	keyDER, _ := x509.MarshalECPrivateKey(clientKey)
+	writePEM("client.key", "EC PRIVATE KEY", keyDER)
+
+	serverDER, serverKey := issue(3, "server", x509.ExtKeyUsageServerAuth)
+
+	return tls.Certificate{Certificate: [][]byte{serverDER}, PrivateKey: serverKey}
+}
+
+func TestTLS(t *testing.T) {
+	dir := t.TempDir()
+	serverCert := writeTestPKI(t, dir)
+
+	data, _ := os.ReadFile(filepath.Join(dir, "ca.pem"))
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(data)
+
+	fa := &fakeAdmin{events: testEvents()}
+	addr := startFakeAdmin(t, fa, grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})))
+
+	code, stdout, stderr := runCmd(t, "-addr", addr, "-consumer", "logger",
+		"-ca", filepath.Join(dir, "ca.pem"), "-cert", filepath.Join(dir, "client.pem"), "-key", filepath.Join(dir, "client.key"),
+		"tail")
+	if code != exitOK || strings.Count(stdout, "\n") != 2 {
+		t.Fatalf("expected two events, got %d %q: %s", code, stdout, stderr)
+	}
+	if fa.peerCN != "logger" {
+		t.Fatalf("expected the client certificate of logger, got %q", fa.peerCN)
+	}
+}
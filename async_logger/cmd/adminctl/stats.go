@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"coursera/hw7_microservice/api"
+	"coursera/hw7_microservice/client"
+)
+
+// clearScreen moves the cursor home and clears the terminal.
+const clearScreen = "\x1b[H\x1b[2J"
+
+func parseStats(args []string, stderr io.Writer) (command, error) {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	interval := fs.Uint64("interval", 1, "seconds between updates")
+	mode := fs.String("mode", "interval", "interval, cumulative, window or server")
+	window := fs.Uint64("window", 60, "seconds covered in window mode")
+	top := fs.Int("top", 20, "rows per table, 0 shows all")
+	refresh := fs.Bool("refresh", isTerminal(os.Stdout), "redraw the screen instead of appending tables")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	value, ok := api.StatInterval_Mode_value[strings.ToUpper(*mode)]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown mode %q\n", *mode)
+		return nil, fmt.Errorf("unknown mode %q", *mode)
+	}
+
+	si := &api.StatInterval{
+		IntervalSeconds: *interval,
+		Mode:            api.StatInterval_Mode(value),
+		WindowSeconds:   *window,
+	}
+
+	return func(ctx context.Context, c *client.Client, stdout io.Writer) error {
+		stream, err := c.Statistics(ctx, si)
+		if err != nil {
+			return err
+		}
+
+		for {
+			stat, err := stream.Recv()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			if *refresh {
+				io.WriteString(stdout, clearScreen)
+			}
+			renderStat(stdout, stat, *top)
+			if !*refresh {
+				fmt.Fprintln(stdout)
+			}
+		}
+	}, nil
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// renderStat prints the per method table and the consumer and status code
+// counters, busiest first.
+func renderStat(w io.Writer, stat *api.Stat, top int) {
+	fmt.Fprintf(w, "%s", time.Unix(stat.Timestamp, 0).Format("2006-01-02 15:04:05"))
+	if stat.Dropped > 0 {
+		fmt.Fprintf(w, "  %d events dropped", stat.Dropped)
+	}
+	fmt.Fprintf(w, "\n\n")
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "METHOD\tCALLS\tERRORS\tP50 ms\tP90 ms\tP99 ms\t\n")
+	for _, method := range busiest(stat.ByMethod, top) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t", method, stat.ByMethod[method], stat.ErrorsByMethod[method])
+		if l := stat.LatencyByMethod[method]; l != nil {
+			fmt.Fprintf(tw, "%.2f\t%.2f\t%.2f\t\n", l.P50Ms, l.P90Ms, l.P99Ms)
+		} else {
+			fmt.Fprintf(tw, "-\t-\t-\t\n")
+		}
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+
+	renderCounters(w, "CONSUMER", stat.ByConsumer, top)
+	renderCounters(w, "CODE", stat.ByCode, top)
+}
+
+func renderCounters(w io.Writer, title string, counts map[string]uint64, top int) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tCALLS\t\n", title)
+	for _, key := range busiest(counts, top) {
+		name := key
+		if name == "" {
+			name = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t\n", name, counts[key])
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+}
+
+// busiest returns up to top keys with the largest counts, ties by name.
+func busiest(counts map[string]uint64, top int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	if top > 0 && len(keys) > top {
+		keys = keys[:top]
+	}
+
+	return keys
+}
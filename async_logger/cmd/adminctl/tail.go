@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"coursera/hw7_microservice/api"
+	"coursera/hw7_microservice/client"
+)
+
+func parseTail(args []string, stderr io.Writer) (command, error) {
+	fs := flag.NewFlagSet("tail", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var consumers, methods, hosts listFlag
+	fs.Var(&consumers, "of", "only calls of this consumer, may be repeated")
+	fs.Var(&methods, "method", "only methods matching this pattern, may be repeated")
+	fs.Var(&hosts, "host", "only hosts matching this pattern, may be repeated")
+	outcome := fs.String("outcome", "any", "any, allowed or denied")
+	results := fs.Bool("results", false, "also print how calls have ended")
+	fromSeq := fs.Uint64("from-seq", 0, "start with journaled events from this sequence number")
+	asJSON := fs.Bool("json", false, "print events as JSON lines")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	value, ok := api.LogFilter_Outcome_value[strings.ToUpper(*outcome)]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown outcome %q\n", *outcome)
+		return nil, fmt.Errorf("unknown outcome %q", *outcome)
+	}
+
+	filter := &api.LogFilter{
+		Consumers:   consumers,
+		Methods:     methods,
+		Hosts:       hosts,
+		Outcome:     api.LogFilter_Outcome(value),
+		FromSeq:     *fromSeq,
+		WithResults: *results,
+	}
+
+	return func(ctx context.Context, c *client.Client, stdout io.Writer) error {
+		return tail(ctx, c, filter, *asJSON, stdout)
+	}, nil
+}
+
+func tail(ctx context.Context, c *client.Client, filter *api.LogFilter, asJSON bool, stdout io.Writer) error {
+	stream, err := c.Logging(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	for {
+		e, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if asJSON {
+			data, err := protojson.Marshal(e)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(stdout, "%s\n", data)
+		} else {
+			fmt.Fprintln(stdout, formatEvent(e))
+		}
+	}
+}
+
+// formatEvent renders e as a single line:
+//
+//	2026-01-02T15:04:05Z #42 biz_user 127.0.0.1:5000 /main.Biz/Check denied: no rule ...
+func formatEvent(e *api.Event) string {
+	var b strings.Builder
+
+	b.WriteString(time.Unix(e.Timestamp, 0).UTC().Format(time.RFC3339))
+	if e.Seq != 0 {
+		fmt.Fprintf(&b, " #%d", e.Seq)
+	}
+
+	consumer := e.Consumer
+	if consumer == "" {
+		consumer = "-"
+	}
+	fmt.Fprintf(&b, " %s %s %s", consumer, e.Host, e.Method)
+
+	switch {
+	case e.Result != nil:
+		fmt.Fprintf(&b, " -> %s in %s", e.Result.Code, time.Duration(e.Result.LatencyMicros)*time.Microsecond)
+	case e.Alert != nil:
+		fmt.Fprintf(&b, " alert %s: %s", e.Alert.Rule, e.Alert.Message)
+	case e.AclChange != nil:
+		fmt.Fprintf(&b, " acl from %s", e.AclChange.Source)
+		if e.AclChange.Error != "" {
+			fmt.Fprintf(&b, " rejected: %s", e.AclChange.Error)
+		}
+		for _, d := range e.AclChange.Diff {
+			fmt.Fprintf(&b, " %s(+%d -%d)", d.Consumer, len(d.Granted), len(d.Revoked))
+		}
+	case e.Throttled:
+		fmt.Fprintf(&b, " throttled: %s", e.Reason)
+	case e.Denied:
+		fmt.Fprintf(&b, " denied: %s", e.Reason)
+	}
+
+	if e.TraceId != "" {
+		fmt.Fprintf(&b, " trace=%s", e.TraceId)
+	}
+	if e.Dropped > 0 {
+		fmt.Fprintf(&b, " (%d events dropped before)", e.Dropped)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+)
+
+// controlSession is the state of one Admin.Control stream. Everything but
+// reading commands happens in the goroutine of the handler, so Send is
+// never called concurrently.
+type controlSession struct {
+	admin  *AdminServerImpl
+	srv    Admin_ControlServer
+	subs   map[int]*Subscription
+	paused bool
+	scope  tenantScope // taken when the stream opens
+
+	// results are counted all the time, pausing only holds back events;
+	// missed results are counted as dropped, the stream is never cut
+	stats     *Subscription
+	sinceOpen *StatisticsCollector
+	sinceLast *StatisticsCollector
+	dropped   uint64
+}
+
+// Control lets an operator change what the stream delivers without opening
+// a new one: add and remove Logging-like subscriptions, take statistics
+// snapshots and pause delivery. Events of a paused stream wait in the
+// subscription buffers and are dropped as usual once those are full.
+func (s *AdminServerImpl) Control(srv Admin_ControlServer) error {
+	scope := s.scope(srv.Context())
+	cs := &controlSession{
+		admin:     s,
+		srv:       srv,
+		subs:      map[int]*Subscription{},
+		scope:     scope,
+		stats:     s.subs.newSub((&eventFilter{results: true}).within(scope), DropOldest),
+		sinceOpen: newStatisticsCollector(),
+		sinceLast: newStatisticsCollector(),
+	}
+	defer func() {
+		s.subs.RemoveSub(cs.stats.ID)
+		for id := range cs.subs {
+			s.subs.RemoveSub(id)
+		}
+	}()
+
+	type received struct {
+		req *ControlRequest
+		err error
+	}
+	requests := make(chan received)
+	go func() {
+		for {
+			req, err := srv.Recv()
+			select {
+			case requests <- received{req, err}:
+			case <-srv.Context().Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		cases, ids := cs.selectCases(requests)
+		chosen, value, ok := reflect.Select(cases)
+
+		switch {
+		case chosen == 0:
+			r := value.Interface().(received)
+			if r.err == io.EOF {
+				return nil
+			}
+			if r.err != nil {
+				return r.err
+			}
+			if err := cs.handle(r.req); err != nil {
+				return err
+			}
+
+		case chosen == 1:
+			if !ok {
+				return cs.stats.Err()
+			}
+			cs.count(value.Interface().(*Event))
+
+		default:
+			sub := cs.subs[ids[chosen-2]]
+			if !ok {
+				// closed by the overflow policy, the stream goes on
+				delete(cs.subs, sub.ID)
+				msg := &ControlMessage{SubscriptionId: uint64(sub.ID)}
+				msg.Payload = &ControlMessage_Error{Error: fmt.Sprintf("subscription closed: %v", sub.Err())}
+				if err := srv.Send(msg); err != nil {
+					return err
+				}
+				continue
+			}
+
+			e := value.Interface().(*Event)
+			if err := cs.sendEvent(sub.ID, withDropped(e, sub.TakeDropped())); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// selectCases lists the channels to wait on: requests, results and then
+// the subscriptions in the order of ids, unless paused.
+func (cs *controlSession) selectCases(requests interface{}) ([]reflect.SelectCase, []int) {
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(requests)},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(cs.stats.Events)},
+	}
+	if cs.paused {
+		return cases, nil
+	}
+
+	ids := make([]int, 0, len(cs.subs))
+	for id := range cs.subs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	for _, id := range ids {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(cs.subs[id].Events)})
+	}
+
+	return cases, ids
+}
+
+func (cs *controlSession) handle(req *ControlRequest) error {
+	reply := &ControlMessage{RequestId: req.RequestId}
+
+	switch cmd := req.Command.(type) {
+	case *ControlRequest_Subscribe:
+		id, err := cs.subscribe(cmd.Subscribe)
+		if err != nil {
+			reply.Payload = &ControlMessage_Error{Error: err.Error()}
+		}
+		reply.SubscriptionId = uint64(id)
+
+	case *ControlRequest_Unsubscribe:
+		id := int(cmd.Unsubscribe)
+		if _, ok := cs.subs[id]; ok {
+			cs.admin.subs.RemoveSub(id)
+			delete(cs.subs, id)
+		} else {
+			reply.Payload = &ControlMessage_Error{Error: fmt.Sprintf("no subscription %d", cmd.Unsubscribe)}
+		}
+		reply.SubscriptionId = cmd.Unsubscribe
+
+	case *ControlRequest_Stats:
+		stat, err := cs.snapshot(cmd.Stats)
+		if err != nil {
+			reply.Payload = &ControlMessage_Error{Error: err.Error()}
+		} else {
+			reply.Payload = &ControlMessage_Stat{Stat: stat}
+		}
+
+	case *ControlRequest_Pause:
+		cs.paused = cmd.Pause
+
+	default:
+		reply.Payload = &ControlMessage_Error{Error: "unknown command"}
+	}
+
+	return cs.srv.Send(reply)
+}
+
+// subscribe adds a subscription, replaying history first if asked to.
+func (cs *controlSession) subscribe(f *LogFilter) (int, error) {
+	filter, err := newEventFilter(f)
+	if err != nil {
+		return 0, err
+	}
+	filter = filter.within(cs.scope)
+
+	if f.FromSeq == 0 && f.FromTimestamp == 0 {
+		sub := cs.admin.subs.NewSub(filter)
+		cs.subs[sub.ID] = sub
+		return sub.ID, nil
+	}
+
+	// the id is unknown until the history is sent, so replayed events
+	// carry none
+	sub, err := cs.admin.subs.NewSubFrom(f.FromSeq, f.FromTimestamp, filter, func(e *Event) error {
+		return cs.sendEvent(0, e)
+	})
+	if err != nil {
+		return 0, err
+	}
+	cs.subs[sub.ID] = sub
+
+	return sub.ID, nil
+}
+
+func (cs *controlSession) sendEvent(id int, e *Event) error {
+	return cs.srv.Send(&ControlMessage{
+		SubscriptionId: uint64(id),
+		Payload:        &ControlMessage_Event{Event: e},
+	})
+}
+
+func (cs *controlSession) count(e *Event) {
+	cs.sinceOpen.Update(e)
+	cs.sinceLast.Update(e)
+}
+
+func (cs *controlSession) snapshot(mode StatInterval_Mode) (*Stat, error) {
+	// results of calls that have already returned may still be buffered
+	for pending := true; pending; {
+		select {
+		case e, ok := <-cs.stats.Events:
+			if ok {
+				cs.count(e)
+			}
+			pending = ok
+		default:
+			pending = false
+		}
+	}
+
+	dropped := cs.stats.TakeDropped()
+	cs.dropped += dropped
+
+	var stat *Stat
+	switch mode {
+	case StatInterval_INTERVAL:
+		stat = cs.sinceLast.Collect()
+		stat.Dropped = dropped
+	case StatInterval_CUMULATIVE:
+		stat = cs.sinceOpen.Snapshot()
+		stat.Dropped = cs.dropped
+	case StatInterval_SERVER:
+		if !cs.scope.all {
+			return nil, fmt.Errorf("server statistics span all tenants")
+		}
+		stat = cs.admin.totals.Snapshot()
+	default:
+		return nil, fmt.Errorf("mode %v is not supported", mode)
+	}
+
+	return stat, nil
+}
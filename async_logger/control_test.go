@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"testing"
+)
+
+const controlACLData string = `{
+	"operator":  ["/main.Admin/Control"],
+	"biz_user":  ["/main.Biz/Check", "/main.Biz/Add"],
+	"biz_admin": ["/main.Biz/*"]
+}`
+
+func TestControl(t *testing.T) {
+	ctx, finish := context.WithCancel(context.Background())
+	err := StartMyMicroservice(ctx, listenAddr, controlACLData)
+	if err != nil {
+		t.Fatalf("cant start server initial: %v", err)
+	}
+	wait(1)
+	defer func() {
+		finish()
+		wait(1)
+	}()
+
+	conn := getGrpcConn(t)
+	defer conn.Close()
+
+	biz := NewBizClient(conn)
+	stream, err := NewAdminClient(conn).Control(getConsumerCtx("operator"))
+	if err != nil {
+		t.Fatalf("cant open control stream: %v", err)
+	}
+
+	send := func(req *ControlRequest) {
+		if err := stream.Send(req); err != nil {
+			t.Fatalf("cant send %v: %v", req, err)
+		}
+	}
+	recv := func() *ControlMessage {
+		msg, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return msg
+	}
+	reply := func(requestID uint64) *ControlMessage {
+		msg := recv()
+		if msg.RequestId != requestID {
+			t.Fatalf("expected reply to %d, got %v", requestID, msg)
+		}
+		return msg
+	}
+
+	send(&ControlRequest{RequestId: 1, Command: &ControlRequest_Subscribe{Subscribe: &LogFilter{Consumers: []string{"biz_user"}}}})
+	byConsumer := reply(1)
+	send(&ControlRequest{RequestId: 2, Command: &ControlRequest_Subscribe{Subscribe: &LogFilter{Methods: []string{"/main.Biz/Add"}}}})
+	byMethod := reply(2)
+	if byConsumer.GetError() != "" || byMethod.GetError() != "" || byConsumer.SubscriptionId == byMethod.SubscriptionId {
+		t.Fatalf("unexpected subscribe replies %v %v", byConsumer, byMethod)
+	}
+
+	biz.Check(getConsumerCtx("biz_user"), &CounterKey{})
+	biz.Add(getConsumerCtx("biz_admin"), &CounterDelta{})
+
+	// both subscriptions deliver at the same time, so the order is up to them
+	events := map[uint64]string{}
+	for i := 0; i < 2; i++ {
+		msg := recv()
+		events[msg.SubscriptionId] = msg.GetEvent().GetMethod()
+	}
+	expected := map[uint64]string{
+		byConsumer.SubscriptionId: "/main.Biz/Check",
+		byMethod.SubscriptionId:   "/main.Biz/Add",
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("events dont match\nhave %+v\nwant %+v", events, expected)
+	}
+
+	// paused streams still count calls
+	send(&ControlRequest{RequestId: 3, Command: &ControlRequest_Pause{Pause: true}})
+	reply(3)
+	biz.Check(getConsumerCtx("biz_user"), &CounterKey{})
+	send(&ControlRequest{RequestId: 4, Command: &ControlRequest_Stats{Stats: StatInterval_INTERVAL}})
+	stat := reply(4).GetStat()
+	expectedByMethod := map[string]uint64{"/main.Biz/Check": 2, "/main.Biz/Add": 1}
+	if !reflect.DeepEqual(stat.GetByMethod(), expectedByMethod) || stat.ByCode["OK"] != 3 {
+		t.Fatalf("unexpected stat %v", stat)
+	}
+
+	send(&ControlRequest{RequestId: 5, Command: &ControlRequest_Pause{Pause: false}})
+	reply(5)
+	if msg := recv(); msg.SubscriptionId != byConsumer.SubscriptionId || msg.GetEvent().GetMethod() != "/main.Biz/Check" {
+		t.Fatalf("expected the held back event, got %v", msg)
+	}
+
+	send(&ControlRequest{RequestId: 6, Command: &ControlRequest_Stats{Stats: StatInterval_INTERVAL}})
+	if stat := reply(6).GetStat(); len(stat.GetByMethod()) != 0 {
+		t.Fatalf("expected nothing since the last snapshot, got %v", stat)
+	}
+	send(&ControlRequest{RequestId: 7, Command: &ControlRequest_Stats{Stats: StatInterval_CUMULATIVE}})
+	if stat := reply(7).GetStat(); !reflect.DeepEqual(stat.GetByMethod(), expectedByMethod) {
+		t.Fatalf("unexpected cumulative stat %v", stat)
+	}
+
+	send(&ControlRequest{RequestId: 8, Command: &ControlRequest_Unsubscribe{Unsubscribe: byConsumer.SubscriptionId}})
+	if msg := reply(8); msg.GetError() != "" {
+		t.Fatalf("unexpected error %v", msg)
+	}
+	biz.Check(getConsumerCtx("biz_user"), &CounterKey{})
+
+	for _, req := range []*ControlRequest{
+		{RequestId: 9, Command: &ControlRequest_Unsubscribe{Unsubscribe: byConsumer.SubscriptionId}},
+		{RequestId: 10, Command: &ControlRequest_Stats{Stats: StatInterval_WINDOW}},
+		{RequestId: 11, Command: &ControlRequest_Subscribe{Subscribe: &LogFilter{Methods: []string{"["}}}},
+		{RequestId: 12},
+	} {
+		send(req)
+		if msg := reply(req.RequestId); msg.GetError() == "" {
+			t.Fatalf("expected error for %v, got %v", req, msg)
+		}
+	}
+
+	stream.CloseSend()
+	if msg, err := stream.Recv(); err != io.EOF {
+		t.Fatalf("expected stream to end, got %v %v", msg, err)
+	}
+}
+
+type blockingControlStream struct {
+	Admin_ControlServer
+	requests chan *ControlRequest
+	sent     chan *ControlMessage
+	resume   chan struct{}
+}
+
+func (s *blockingControlStream) Recv() (*ControlRequest, error) {
+	req, ok := <-s.requests
+	if !ok {
+		return nil, io.EOF
+	}
+	return req, nil
+}
+
+func (s *blockingControlStream) Send(msg *ControlMessage) error {
+	s.sent <- msg
+	<-s.resume
+	return nil
+}
+
+func (s *blockingControlStream) Context() context.Context {
+	return context.Background()
+}
+
+// TestControlStatsOverflow checks that results the stream had no time to
+// count are dropped, while the stream goes on even under the Disconnect
+// policy
+func TestControlStatsOverflow(t *testing.T) {
+	subs := newEventSubs(1, Disconnect)
+	srv := &blockingControlStream{
+		requests: make(chan *ControlRequest),
+		sent:     make(chan *ControlMessage),
+		resume:   make(chan struct{}),
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- NewAdminServer(subs, nil, nil).Control(srv)
+	}()
+
+	// a stream cut short is an error, not a hang
+	sent := func() *ControlMessage {
+		select {
+		case msg := <-srv.sent:
+			return msg
+		case err := <-done:
+			t.Fatalf("stream ended: %v", err)
+			return nil
+		}
+	}
+	statsRequest := &ControlRequest{Command: &ControlRequest_Stats{Stats: StatInterval_CUMULATIVE}}
+
+	// the stream is stuck sending the reply while results pile up
+	srv.requests <- statsRequest
+	sent()
+	for i := 0; i < 5; i++ {
+		subs.Notify(&Event{Method: "/main.Biz/Check", Result: &CallResult{Code: "OK"}})
+	}
+	srv.resume <- struct{}{}
+
+	srv.requests <- statsRequest
+	stat := sent().GetStat()
+	srv.resume <- struct{}{}
+	if stat == nil || stat.ByCode["OK"] != 1 || stat.Dropped != 4 {
+		t.Fatalf("expected 1 result counted and 4 dropped, got %v", stat)
+	}
+
+	close(srv.requests)
+	if err := <-done; err != nil {
+		t.Fatalf("expected the stream to end normally, got %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"path"
+)
+
+// eventFilter decides which events a Logging subscriber wants to see.
+// Empty lists match everything, non-empty ones need at least one hit.
+// Call results are only delivered when asked for.
+type eventFilter struct {
+	consumers map[string]struct{}
+	methods   []string
+	hosts     []string
+	outcome   LogFilter_Outcome
+	results   bool
+	scoped    bool // only events of tenant pass
+	tenant    string
+	alerts    bool // only alerts pass
+}
+
+func newEventFilter(f *LogFilter) (*eventFilter, error) {
+	if f == nil {
+		return nil, nil
+	}
+
+	for _, pattern := range append(append([]string{}, f.Methods...), f.Hosts...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("bad pattern %q: %v", pattern, err)
+		}
+	}
+
+	if _, ok := LogFilter_Outcome_name[int32(f.Outcome)]; !ok {
+		return nil, fmt.Errorf("unknown outcome %d", f.Outcome)
+	}
+
+	ef := &eventFilter{
+		methods: f.Methods,
+		hosts:   f.Hosts,
+		outcome: f.Outcome,
+		results: f.WithResults,
+	}
+
+	if len(f.Consumers) > 0 {
+		ef.consumers = make(map[string]struct{}, len(f.Consumers))
+		for _, consumer := range f.Consumers {
+			ef.consumers[consumer] = struct{}{}
+		}
+	}
+
+	return ef, nil
+}
+
+func (ef *eventFilter) Match(e *Event) bool {
+	if ef == nil {
+		return e.Result == nil
+	}
+	if e.Result != nil && !ef.results {
+		return false
+	}
+	if ef.alerts && e.Alert == nil {
+		return false
+	}
+	// an ACL change describes every tenant
+	if ef.scoped && (e.Tenant != ef.tenant || e.AclChange != nil) {
+		return false
+	}
+
+	// only calls have an outcome
+	if ef.outcome != LogFilter_ANY && (e.Alert != nil || e.AclChange != nil) {
+		return false
+	}
+	switch ef.outcome {
+	case LogFilter_ALLOWED:
+		if e.Denied {
+			return false
+		}
+	case LogFilter_DENIED:
+		if !e.Denied {
+			return false
+		}
+	}
+
+	if ef.consumers != nil {
+		if _, ok := ef.consumers[e.Consumer]; !ok {
+			return false
+		}
+	}
+
+	return matchAny(ef.methods, e.Method) && matchAny(ef.hosts, e.Host)
+}
+
+// within restricts ef to the events scope may see.
+func (ef *eventFilter) within(scope tenantScope) *eventFilter {
+	if scope.all {
+		return ef
+	}
+
+	restricted := &eventFilter{}
+	if ef != nil {
+		*restricted = *ef
+	}
+	restricted.scoped, restricted.tenant = true, scope.tenant
+
+	return restricted
+}
+
+func matchAny(patterns []string, s string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, s); ok {
+			return true
+		}
+	}
+
+	return false
+}
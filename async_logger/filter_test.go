@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func TestEventFilter(t *testing.T) {
+	events := []*Event{
+		{Consumer: "biz_user", Method: "/main.Biz/Check", Host: "127.0.0.1:5000"},
+		{Consumer: "biz_user", Method: "/main.Biz/Test", Host: "127.0.0.1:5000", Denied: true},
+		{Consumer: "biz_admin", Method: "/main.Biz/Test", Host: "10.0.0.1:6000"},
+		{Consumer: "stat1", Method: "/main.Admin/Statistics", Host: "10.0.0.1:6001", Denied: true},
+		{Consumer: "biz_user", Method: "/main.Biz/Check", Host: "127.0.0.1:5000", Result: &CallResult{Code: "OK"}},
+	}
+
+	for idx, tc := range []struct {
+		filter  *LogFilter
+		matched []int
+	}{
+		{&LogFilter{}, []int{0, 1, 2, 3}},
+		{&LogFilter{Consumers: []string{"biz_user", "stat1"}}, []int{0, 1, 3}},
+		{&LogFilter{Methods: []string{"/main.Biz/*"}}, []int{0, 1, 2}},
+		{&LogFilter{Methods: []string{"/*/Test", "/main.Admin/*"}}, []int{1, 2, 3}},
+		{&LogFilter{Hosts: []string{"10.0.0.1:*"}}, []int{2, 3}},
+		{&LogFilter{Outcome: LogFilter_DENIED}, []int{1, 3}},
+		{&LogFilter{Outcome: LogFilter_ALLOWED}, []int{0, 2}},
+		{&LogFilter{Consumers: []string{"biz_user"}, Outcome: LogFilter_DENIED}, []int{1}},
+		{&LogFilter{Consumers: []string{"biz_user"}, WithResults: true}, []int{0, 1, 4}},
+	} {
+		filter, err := newEventFilter(tc.filter)
+		if err != nil {
+			t.Fatalf("[%d] unexpected error: %v", idx, err)
+		}
+
+		matched := []int{}
+		for i, e := range events {
+			if filter.Match(e) {
+				matched = append(matched, i)
+			}
+		}
+		if len(matched) != len(tc.matched) {
+			t.Fatalf("[%d] expected %v, got %v", idx, tc.matched, matched)
+		}
+		for i := range matched {
+			if matched[i] != tc.matched[i] {
+				t.Fatalf("[%d] expected %v, got %v", idx, tc.matched, matched)
+			}
+		}
+	}
+
+	for idx, f := range []*LogFilter{
+		{Methods: []string{"/main.Biz/[Check"}},
+		{Hosts: []string{"["}},
+		{Outcome: 42},
+	} {
+		if _, err := newEventFilter(f); err == nil {
+			t.Fatalf("[%d] expected error on bad filter", idx)
+		}
+	}
+}
+
+func TestLoggingFilter(t *testing.T) {
+	ctx, finish := context.WithCancel(context.Background())
+	err := StartMyMicroservice(ctx, listenAddr, ACLData)
+	if err != nil {
+		t.Fatalf("cant start server initial: %v", err)
+	}
+	wait(1)
+	defer func() {
+		finish()
+		wait(1)
+	}()
+
+	conn := getGrpcConn(t)
+	defer conn.Close()
+
+	biz := NewBizClient(conn)
+	adm := NewAdminClient(conn)
+
+	badStream, err := adm.Logging(getConsumerCtx("logger1"), &LogFilter{Methods: []string{"["}})
+	if err == nil {
+		_, err = badStream.Recv()
+	}
+	if code := grpc.Code(err); code != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for bad filter, got %v", err)
+	}
+
+	logStream, err := adm.Logging(getConsumerCtx("logger1"), &LogFilter{
+		Consumers: []string{"biz_user", "unknown"},
+		Outcome:   LogFilter_DENIED,
+	})
+	if err != nil {
+		t.Fatalf("cant open log stream: %v", err)
+	}
+	wait(1)
+
+	biz.Check(getConsumerCtx("biz_user"), &CounterKey{})
+	biz.Test(getConsumerCtx("biz_admin"), &CounterBatch{})
+	biz.Test(getConsumerCtx("biz_user"), &CounterBatch{})
+	biz.Add(getConsumerCtx("unknown"), &CounterDelta{})
+
+	for idx, want := range []*Event{
+		{Consumer: "biz_user", Method: "/main.Biz/Test"},
+		{Consumer: "unknown", Method: "/main.Biz/Add"},
+	} {
+		evt, err := logStream.Recv()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if evt.Consumer != want.Consumer || evt.Method != want.Method || !evt.Denied {
+			t.Fatalf("[%d] expected denied %v, got %v", idx, want, evt)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+)
+
+const toolingACLData string = `{
+	"logger":  ["/main.Admin/Logging"],
+	"tooling": ["/grpc.reflection.v1alpha.ServerReflection/*"]
+}`
+
+func TestHealth(t *testing.T) {
+	ctx, finish := context.WithCancel(context.Background())
+	err := StartMyMicroservice(ctx, listenAddr, toolingACLData)
+	if err != nil {
+		t.Fatalf("cant start server initial: %v", err)
+	}
+	wait(1)
+	defer func() {
+		finish()
+		wait(1)
+	}()
+
+	conn := getGrpcConn(t)
+	defer conn.Close()
+
+	adm := NewAdminClient(conn)
+	hc := healthpb.NewHealthClient(conn)
+	rc := rpb.NewServerReflectionClient(conn)
+
+	logStream, err := adm.Logging(getConsumerCtx("logger"), &LogFilter{})
+	if err != nil {
+		t.Fatalf("cant open log stream: %v", err)
+	}
+	wait(1)
+
+	// no consumer needed
+	for _, service := range []string{"", "main.Biz", "main.Admin"} {
+		resp, err := hc.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", service, err)
+		}
+		if resp.Status != healthpb.HealthCheckResponse_SERVING {
+			t.Fatalf("expected %q to be serving, got %v", service, resp.Status)
+		}
+	}
+	_, err = hc.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "main.Unknown"})
+	if code := grpc.Code(err); code != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+
+	listServices := func(ctx context.Context) ([]string, error) {
+		stream, err := rc.ServerReflectionInfo(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer stream.CloseSend()
+
+		err = stream.Send(&rpb.ServerReflectionRequest{
+			MessageRequest: &rpb.ServerReflectionRequest_ListServices{ListServices: "*"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+
+		var services []string
+		for _, s := range resp.GetListServicesResponse().GetService() {
+			services = append(services, s.Name)
+		}
+		return services, nil
+	}
+
+	if _, err := listServices(context.Background()); grpc.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated, got %v", err)
+	}
+	services, err := listServices(getConsumerCtx("tooling"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	joined := strings.Join(services, ",")
+	for _, service := range []string{"main.Admin", "main.Biz", "grpc.health.v1.Health"} {
+		if !strings.Contains(joined, service) {
+			t.Fatalf("expected %s in %v", service, services)
+		}
+	}
+
+	// health checks are not logged, reflection calls are
+	for _, consumer := range []string{"", "tooling"} {
+		evt, err := logStream.Recv()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if evt.Consumer != consumer || evt.Method != "/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo" {
+			t.Fatalf("expected reflection call of %q, got %v", consumer, evt)
+		}
+	}
+}
+
+func TestDrain(t *testing.T) {
+	ctx, finish := context.WithCancel(context.Background())
+	err := StartMyMicroservice(ctx, listenAddr, toolingACLData, WithDrainTimeout(300*time.Millisecond))
+	if err != nil {
+		t.Fatalf("cant start server initial: %v", err)
+	}
+	wait(1)
+	defer func() {
+		finish()
+		wait(1)
+	}()
+
+	conn := getGrpcConn(t)
+	defer conn.Close()
+
+	logStream, err := NewAdminClient(conn).Logging(getConsumerCtx("logger"), &LogFilter{})
+	if err != nil {
+		t.Fatalf("cant open log stream: %v", err)
+	}
+	watch, err := healthpb.NewHealthClient(conn).Watch(context.Background(), &healthpb.HealthCheckRequest{Service: "main.Biz"})
+	if err != nil {
+		t.Fatalf("cant watch health: %v", err)
+	}
+	if resp, err := watch.Recv(); err != nil || resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("expected SERVING, got %v %v", resp, err)
+	}
+	wait(1)
+
+	start := time.Now()
+	finish()
+
+	_, err = logStream.Recv()
+	if code := grpc.Code(err); code != codes.Unavailable || !strings.Contains(err.Error(), "shutting down") {
+		t.Fatalf("expected Unavailable, got %v", err)
+	}
+	if resp, err := watch.Recv(); err != nil || resp.Status != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("expected NOT_SERVING, got %v %v", resp, err)
+	}
+
+	// the watch never ends by itself and is cut off after the drain timeout
+	if _, err := watch.Recv(); err == nil {
+		t.Fatalf("expected watch to be closed")
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond || elapsed > 2*time.Second {
+		t.Fatalf("expected force stop after the drain timeout, took %v", elapsed)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import "time"
+
+type latencyHistogram struct {
+	bounds  []int64  // upper bounds of the buckets in microseconds
+	buckets []uint64 // len(bounds)+1
+	count   uint64
+	sum     int64 // microseconds
+}
+
+func newLatencyHistogram() *latencyHistogram {
+	// everything slower than the last bound falls into the last, unbounded
+	// bucket
+	bounds := []int64{
+		50, 100, 250, 500,
+		1000, 2500, 5000, 10000, 25000, 50000,
+		100000, 250000, 500000,
+		1000000, 2500000, 5000000, 10000000,
+	}
+
+	return &latencyHistogram{bounds: bounds, buckets: make([]uint64, len(bounds)+1)}
+}
+
+func (h *latencyHistogram) Observe(micros int64) {
+	i := 0
+	for i < len(h.bounds) && micros > h.bounds[i] {
+		i++
+	}
+
+	h.buckets[i]++
+	h.count++
+	h.sum += micros
+}
+
+func (h *latencyHistogram) Merge(other *latencyHistogram) {
+	for i, n := range other.buckets {
+		h.buckets[i] += n
+	}
+	h.count += other.count
+	h.sum += other.sum
+}
+
+// Quantile estimates the q-th quantile by interpolating inside the bucket
+// it falls into.
+func (h *latencyHistogram) Quantile(q float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+
+	rank := q * float64(h.count)
+	var seen uint64
+	for i, n := range h.buckets {
+		if n == 0 || float64(seen+n) < rank {
+			seen += n
+			continue
+		}
+
+		lower := int64(0)
+		if i > 0 {
+			lower = h.bounds[i-1]
+		}
+		if i == len(h.bounds) {
+			// nothing to interpolate against in the unbounded bucket
+			return time.Duration(lower) * time.Microsecond
+		}
+
+		fraction := (rank - float64(seen)) / float64(n)
+		return time.Duration(float64(lower)+fraction*float64(h.bounds[i]-lower)) * time.Microsecond
+	}
+
+	return time.Duration(h.bounds[len(h.bounds)-1]) * time.Microsecond
+}
+
+func (h *latencyHistogram) Latency() *Latency {
+	ms := func(d time.Duration) float64 {
+		return float64(d) / float64(time.Millisecond)
+	}
+
+	return &Latency{
+		Count: h.count,
+		P50Ms: ms(h.Quantile(0.5)),
+		P90Ms: ms(h.Quantile(0.9)),
+		P99Ms: ms(h.Quantile(0.99)),
+	}
+}
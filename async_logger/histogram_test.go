@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyHistogram(t *testing.T) {
+	h := newLatencyHistogram()
+	if q := h.Quantile(0.5); q != 0 {
+		t.Fatalf("expected 0 for empty histogram, got %v", q)
+	}
+
+	// 90 fast calls between 1 and 2.5ms, 10 slow ones between 100 and 250ms
+	for i := 0; i < 90; i++ {
+		h.Observe(2000)
+	}
+	for i := 0; i < 10; i++ {
+		h.Observe(200000)
+	}
+
+	for _, tc := range []struct {
+		q        float64
+		min, max time.Duration
+	}{
+		{0.5, time.Millisecond, 2500 * time.Microsecond},
+		{0.9, time.Millisecond, 2500 * time.Microsecond},
+		{0.99, 100 * time.Millisecond, 250 * time.Millisecond},
+	} {
+		if v := h.Quantile(tc.q); v < tc.min || v > tc.max {
+			t.Fatalf("p%v: expected between %v and %v, got %v", tc.q*100, tc.min, tc.max, v)
+		}
+	}
+
+	h.Observe(time.Minute.Microseconds())
+	if v := h.Quantile(1); v != 10*time.Second {
+		t.Fatalf("expected the last bound for the unbounded bucket, got %v", v)
+	}
+
+	other := newLatencyHistogram()
+	other.Observe(10)
+	h.Merge(other)
+	if l := h.Latency(); l.Count != 102 {
+		t.Fatalf("expected 102 observations after merge, got %d", l.Count)
+	}
+}
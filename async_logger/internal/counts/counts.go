@@ -0,0 +1,15 @@
+// Package counts holds helpers for the per-key counters of statistics,
+// shared by the server and the client package.
+package counts
+
+// Add adds src to dst, allocating dst if needed.
+func Add(dst map[string]uint64, src map[string]uint64) map[string]uint64 {
+	if dst == nil {
+		dst = make(map[string]uint64, len(src))
+	}
+	for key, n := range src {
+		dst[key] += n
+	}
+
+	return dst
+}
@@ -0,0 +1,547 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const journalExt = ".journal"
+
+// journalRunningFile exists in the journal directory while a journal is
+// open, finding it on open means the previous process died without Close.
+const journalRunningFile = "running"
+
+type JournalConfig struct {
+	Dir string
+	// a new segment is started once the current one grows past this size
+	MaxSegmentBytes int64
+	// oldest segments are removed when there are more of them than this,
+	// or when they were last written before MaxAge; zero disables the limit
+	MaxSegments int
+	MaxAge      time.Duration
+	// events waiting for the writer, 4096 when zero
+	QueueSize int
+}
+
+// Journal is an append-only log of events split into segment files named
+// after the sequence number of their first event. Every record is a
+// big-endian uint32 length followed by the marshalled Event.
+//
+// Events the writer could not keep up with are dropped, and the next event
+// starts a new segment, so a gap between segments is where they were.
+// Sequence numbers are never issued twice: Close leaves an empty segment
+// named after the next number, and after a crash numbering skips over
+// whatever could have been queued.
+type Journal struct {
+	cfg JournalConfig
+
+	mux     sync.Mutex
+	cond    *sync.Cond
+	queue   chan *Event
+	queueMu sync.RWMutex // guards sending to queue against closing it
+	closed  bool
+	lastSeq uint64 // last sequence number accepted by Append
+	written uint64 // every event up to it is flushed to disk or dropped
+	err     error  // the last write error, until a write succeeds
+	dropped uint64 // events that never made it to disk
+	done    chan struct{}
+
+	file   *os.File
+	buf    *bufio.Writer
+	size   int64
+	onDisk uint64 // last sequence number in the segments, owned by run
+}
+
+func OpenJournal(cfg JournalConfig) (*Journal, error) {
+	if cfg.MaxSegmentBytes <= 0 {
+		cfg.MaxSegmentBytes = 64 << 20
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 4096
+	}
+
+	if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
+		return nil, err
+	}
+
+	j := &Journal{
+		cfg:   cfg,
+		queue: make(chan *Event, cfg.QueueSize),
+		done:  make(chan struct{}),
+	}
+	j.cond = sync.NewCond(&j.mux)
+
+	running := filepath.Join(cfg.Dir, journalRunningFile)
+	_, err := os.Stat(running)
+	crashed := err == nil
+
+	segments, err := j.segments()
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) > 0 {
+		if err := j.recover(segments[len(segments)-1]); err != nil {
+			return nil, err
+		}
+	}
+	j.onDisk = j.lastSeq
+
+	if crashed {
+		// subscribers may have seen events which were queued or being
+		// written, and a batch is never longer than the queue
+		j.lastSeq += 2 * uint64(cfg.QueueSize)
+		if err := j.rotate(j.lastSeq + 1); err != nil {
+			j.closeSegment()
+			return nil, err
+		}
+	}
+	j.written = j.lastSeq
+
+	if err := os.WriteFile(running, nil, 0644); err != nil {
+		j.closeSegment()
+		return nil, err
+	}
+
+	go j.run()
+
+	return j, nil
+}
+
+// recover finds the last complete record of a segment and cuts off
+// whatever a crash may have left after it.
+func (j *Journal) recover(segment string) error {
+	f, err := os.OpenFile(segment, os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+
+	var size int64
+	if first := segmentFirstSeq(segment); first > 0 {
+		j.lastSeq = first - 1
+	}
+
+	r := bufio.NewReader(f)
+	for {
+		e, n, err := readRecord(r)
+		if err != nil {
+			break
+		}
+		size += n
+		j.lastSeq = e.Seq
+	}
+
+	if err := f.Truncate(size); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Seek(size, io.SeekStart); err != nil {
+		f.Close()
+		return err
+	}
+
+	j.file = f
+	j.buf = bufio.NewWriter(f)
+	j.size = size
+
+	return nil
+}
+
+func (j *Journal) LastSeq() uint64 {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	return j.lastSeq
+}
+
+// Written returns the sequence number up to which every event is either
+// readable from disk or dropped.
+func (j *Journal) Written() uint64 {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	return j.written
+}
+
+// Append queues e for writing and never blocks, as it is called under the
+// lock of EventSubs.Notify. When the queue is full the event is dropped,
+// which leaves a gap in the journal, and the writer goes on with the next
+// one.
+func (j *Journal) Append(e *Event) {
+	j.queueMu.RLock()
+	defer j.queueMu.RUnlock()
+
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	if j.closed {
+		return
+	}
+	j.lastSeq = e.Seq
+
+	select {
+	case j.queue <- e:
+	default:
+		j.dropped++
+	}
+}
+
+// Err returns the last write error, nil once a write succeeds again.
+func (j *Journal) Err() error {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	return j.err
+}
+
+// Dropped returns the number of events that were not written because the
+// writer fell behind or failed to write them.
+func (j *Journal) Dropped() uint64 {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	return j.dropped
+}
+
+// WaitWritten blocks until every event up to seq is on disk or dropped,
+// Replay tells which.
+func (j *Journal) WaitWritten(seq uint64) error {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+
+	for j.written < seq && !j.closed {
+		j.cond.Wait()
+	}
+
+	if j.written < seq {
+		return fmt.Errorf("journal closed")
+	}
+
+	return nil
+}
+
+func (j *Journal) Close() error {
+	j.queueMu.Lock()
+	j.mux.Lock()
+	if j.closed {
+		j.mux.Unlock()
+		j.queueMu.Unlock()
+		return nil
+	}
+	j.closed = true
+	close(j.queue)
+	j.cond.Broadcast()
+	j.mux.Unlock()
+	j.queueMu.Unlock()
+
+	<-j.done
+
+	// the next run continues after the last event taken, which is not on
+	// disk when the tail was dropped or failed to be written
+	var err error
+	if j.file == nil || j.lastSeq > j.onDisk {
+		err = j.rotate(j.lastSeq + 1)
+	}
+	if cerr := j.closeSegment(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(filepath.Join(j.cfg.Dir, journalRunningFile))
+}
+
+func (j *Journal) closeSegment() error {
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+
+	return err
+}
+
+func (j *Journal) run() {
+	defer close(j.done)
+
+	for e := range j.queue {
+		err := j.write(e)
+		batch := uint64(1)
+
+		// flush once the queue is drained so a burst costs one syscall
+		for err == nil && len(j.queue) > 0 && batch < uint64(j.cfg.QueueSize) {
+			e = <-j.queue
+			err = j.write(e)
+			batch++
+		}
+		if err == nil {
+			err = j.buf.Flush()
+		}
+		if err != nil {
+			// the segment may end with a torn record, the next event
+			// starts a new one
+			j.closeSegment()
+		}
+
+		j.mux.Lock()
+		if err != nil {
+			j.err = fmt.Errorf("journal write failed: %v", err)
+			j.dropped += batch
+		} else {
+			j.err = nil
+		}
+		// with nothing queued every event Append took is settled
+		if len(j.queue) == 0 {
+			j.written = j.lastSeq
+		} else {
+			j.written = e.Seq
+		}
+		j.cond.Broadcast()
+		j.mux.Unlock()
+	}
+}
+
+func (j *Journal) write(e *Event) error {
+	// after a gap the next event starts a segment of its own, so that
+	// the gap is seen from the names of the segments after a restart too
+	if j.file == nil || j.size >= j.cfg.MaxSegmentBytes || e.Seq != j.onDisk+1 {
+		if err := j.rotate(e.Seq); err != nil {
+			return err
+		}
+	}
+
+	n, err := writeRecord(j.buf, e)
+	if err != nil {
+		return err
+	}
+	j.size += n
+	j.onDisk = e.Seq
+
+	return nil
+}
+
+// writeRecord writes e prefixed by its length, the counterpart of readRecord.
+func writeRecord(w io.Writer, e *Event) (int64, error) {
+	data, err := proto.Marshal(e)
+	if err != nil {
+		return 0, err
+	}
+
+	var header [4]byte
+	binary.BigEndian.PutUint32(header[:], uint32(len(data)))
+	if _, err := w.Write(header[:]); err != nil {
+		return 0, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return 0, err
+	}
+
+	return int64(len(header) + len(data)), nil
+}
+
+func (j *Journal) rotate(firstSeq uint64) error {
+	if j.file != nil {
+		if err := j.buf.Flush(); err != nil {
+			return err
+		}
+		if err := j.file.Close(); err != nil {
+			return err
+		}
+	}
+
+	j.file = nil
+
+	name := filepath.Join(j.cfg.Dir, fmt.Sprintf("%020d%s", firstSeq, journalExt))
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	j.file = f
+	j.buf = bufio.NewWriter(f)
+	j.size = 0
+	j.onDisk = firstSeq - 1
+
+	return j.enforceRetention()
+}
+
+func (j *Journal) enforceRetention() error {
+	segments, err := j.segments()
+	if err != nil {
+		return err
+	}
+
+	// the last segment is the one being written, it is never removed
+	old := segments[:len(segments)-1]
+	if j.cfg.MaxSegments > 0 && len(segments) > j.cfg.MaxSegments {
+		for _, segment := range old[:len(segments)-j.cfg.MaxSegments] {
+			if err := os.Remove(segment); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		old = old[len(segments)-j.cfg.MaxSegments:]
+	}
+
+	if j.cfg.MaxAge > 0 {
+		for _, segment := range old {
+			info, err := os.Stat(segment)
+			if err != nil || time.Since(info.ModTime()) <= j.cfg.MaxAge {
+				continue
+			}
+			if err := os.Remove(segment); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// segments lists segment files from the oldest to the newest.
+func (j *Journal) segments() ([]string, error) {
+	entries, err := os.ReadDir(j.cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	segments := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), journalExt) {
+			segments = append(segments, filepath.Join(j.cfg.Dir, entry.Name()))
+		}
+	}
+	sort.Strings(segments)
+
+	return segments, nil
+}
+
+func segmentFirstSeq(segment string) uint64 {
+	seq, _ := strconv.ParseUint(strings.TrimSuffix(filepath.Base(segment), journalExt), 10, 64)
+
+	return seq
+}
+
+// JournalGapError is returned by Replay when events in the asked range were
+// dropped or lost in a crash.
+type JournalGapError struct {
+	From uint64
+	To   uint64
+}
+
+func (e *JournalGapError) Error() string {
+	return fmt.Sprintf("events %d to %d are missing from the journal", e.From, e.To)
+}
+
+// Replay calls fn for every event with fromSeq <= Seq <= toSeq and
+// Timestamp >= fromTimestamp, in order, and fails with a JournalGapError
+// on the first missing event. Events removed by retention are not missing,
+// replay starts from the oldest segment then. The caller should make sure
+// toSeq has been written with WaitWritten.
+func (j *Journal) Replay(fromSeq uint64, fromTimestamp int64, toSeq uint64, fn func(*Event) error) error {
+	segments, err := j.segments()
+	if err != nil {
+		return err
+	}
+
+	// the sequence number expected next, zero until it is known
+	var next uint64
+
+	for i, segment := range segments {
+		if i+1 < len(segments) && segmentFirstSeq(segments[i+1]) <= fromSeq {
+			continue
+		}
+		if segmentFirstSeq(segment) > toSeq {
+			break
+		}
+		if next == 0 && segmentFirstSeq(segment) <= fromSeq {
+			next = fromSeq
+		}
+
+		done, err := replaySegment(segment, toSeq, func(e *Event) error {
+			if e.Seq < fromSeq {
+				return nil
+			}
+			if next != 0 && e.Seq != next {
+				return &JournalGapError{From: next, To: e.Seq - 1}
+			}
+			next = e.Seq + 1
+
+			if e.Timestamp < fromTimestamp {
+				return nil
+			}
+			return fn(e)
+		})
+		if err != nil {
+			return err
+		}
+		if done {
+			break
+		}
+	}
+
+	if next != 0 && next <= toSeq {
+		return &JournalGapError{From: next, To: toSeq}
+	}
+
+	return nil
+}
+
+// replaySegment calls fn for every event of segment up to toSeq and tells
+// whether there was anything after it.
+func replaySegment(segment string, toSeq uint64, fn func(*Event) error) (bool, error) {
+	f, err := os.Open(segment)
+	if os.IsNotExist(err) {
+		// removed by retention while we were reading older segments
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for {
+		e, _, err := readRecord(r)
+		if err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+
+		if e.Seq > toSeq {
+			return true, nil
+		}
+		if err := fn(e); err != nil {
+			return false, err
+		}
+	}
+}
+
+func readRecord(r io.Reader) (*Event, int64, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, 0, err
+	}
+
+	data := make([]byte, binary.BigEndian.Uint32(header[:]))
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+
+	e := &Event{}
+	if err := proto.Unmarshal(data, e); err != nil {
+		return nil, 0, err
+	}
+
+	return e, int64(len(header) + len(data)), nil
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func replayAll(t *testing.T, j *Journal, fromSeq uint64, fromTimestamp int64) []uint64 {
+	seqs := []uint64{}
+	err := j.Replay(fromSeq, fromTimestamp, j.Written(), func(e *Event) error {
+		seqs = append(seqs, e.Seq)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+
+	return seqs
+}
+
+func TestJournalRotation(t *testing.T) {
+	cfg := JournalConfig{
+		Dir:             t.TempDir(),
+		MaxSegmentBytes: 200,
+		MaxSegments:     3,
+	}
+
+	j, err := OpenJournal(cfg)
+	if err != nil {
+		t.Fatalf("cant open journal: %v", err)
+	}
+
+	subs := newEventSubs(1, DropNewest)
+	subs.useJournal(j)
+	for i := 0; i < 100; i++ {
+		subs.Notify(&Event{Consumer: "biz_user", Method: "/main.Biz/Check", Timestamp: int64(i)})
+	}
+	if err := j.WaitWritten(100); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	segments, _ := j.segments()
+	if len(segments) != cfg.MaxSegments {
+		t.Fatalf("expected %d segments after retention, got %d", cfg.MaxSegments, len(segments))
+	}
+
+	// old events are gone, what is left is a contiguous tail
+	seqs := replayAll(t, j, 1, 0)
+	if len(seqs) == 0 || seqs[len(seqs)-1] != 100 {
+		t.Fatalf("expected tail ending at 100, got %v", seqs)
+	}
+	for i := 1; i < len(seqs); i++ {
+		if seqs[i] != seqs[i-1]+1 {
+			t.Fatalf("gap in replay: %v", seqs)
+		}
+	}
+
+	if seqs := replayAll(t, j, 95, 0); len(seqs) != 6 || seqs[0] != 95 {
+		t.Fatalf("expected 95..100, got %v", seqs)
+	}
+	if seqs := replayAll(t, j, 0, 98); len(seqs) != 2 || seqs[0] != 99 {
+		t.Fatalf("expected events with timestamp >= 98, got %v", seqs)
+	}
+
+	if err := j.Close(); err != nil {
+		t.Fatalf("cant close journal: %v", err)
+	}
+
+	// simulate a crash in the middle of a record
+	last := segments[len(segments)-1]
+	f, _ := os.OpenFile(last, os.O_APPEND|os.O_WRONLY, 0644)
+	f.Write([]byte{0, 0, 0, 42, 1, 2})
+	f.Close()
+
+	j, err = OpenJournal(cfg)
+	if err != nil {
+		t.Fatalf("cant reopen journal: %v", err)
+	}
+	defer j.Close()
+
+	if seq := j.LastSeq(); seq != 100 {
+		t.Fatalf("expected numbering to continue after 100, got %d", seq)
+	}
+
+	subs = newEventSubs(1, DropNewest)
+	subs.useJournal(j)
+	subs.Notify(&Event{Method: "/main.Biz/Add"})
+	if err := j.WaitWritten(101); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if seqs := replayAll(t, j, 99, 0); len(seqs) != 3 || seqs[2] != 101 {
+		t.Fatalf("expected 99..101 after recovery, got %v", seqs)
+	}
+}
+
+func TestLoggingReplay(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "journal")
+
+	start := func() context.CancelFunc {
+		ctx, finish := context.WithCancel(context.Background())
+		err := StartMyMicroservice(ctx, listenAddr, ACLData, WithJournal(JournalConfig{Dir: dir}))
+		if err != nil {
+			t.Fatalf("cant start server initial: %v", err)
+		}
+		wait(1)
+		return finish
+	}
+
+	finish := start()
+
+	conn := getGrpcConn(t)
+	defer conn.Close()
+
+	biz := NewBizClient(conn)
+	adm := NewAdminClient(conn)
+
+	// nobody listens yet, the journal keeps these
+	biz.Check(getConsumerCtx("biz_user"), &CounterKey{})
+	biz.Add(getConsumerCtx("biz_user"), &CounterDelta{})
+
+	finish()
+	wait(2)
+	finish = start()
+	defer func() {
+		finish()
+		wait(1)
+	}()
+
+	biz.Test(getConsumerCtx("biz_admin"), &CounterBatch{})
+
+	logStream, err := adm.Logging(getConsumerCtx("logger1"), &LogFilter{
+		FromSeq: 1,
+		Methods: []string{"/main.Biz/*"},
+	})
+	if err != nil {
+		t.Fatalf("cant open log stream: %v", err)
+	}
+	wait(1)
+
+	biz.Check(getConsumerCtx("biz_admin"), &CounterKey{})
+
+	expected := []*Event{
+		{Seq: 1, Consumer: "biz_user", Method: "/main.Biz/Check"},
+		{Seq: 2, Consumer: "biz_user", Method: "/main.Biz/Add"},
+		{Seq: 3, Consumer: "biz_admin", Method: "/main.Biz/Test"},
+		// seq 4 is the Logging call itself, filtered out
+		{Seq: 5, Consumer: "biz_admin", Method: "/main.Biz/Check"},
+	}
+	for idx, want := range expected {
+		evt, err := logStream.Recv()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if evt.Seq != want.Seq || evt.Consumer != want.Consumer || evt.Method != want.Method {
+			t.Fatalf("[%d] expected %v, got %v", idx, want, evt)
+		}
+	}
+
+	// resuming after a disconnect
+	resumed, err := adm.Logging(getConsumerCtx("logger1"), &LogFilter{FromSeq: 3})
+	if err != nil {
+		t.Fatalf("cant open log stream: %v", err)
+	}
+	for _, seq := range []uint64{3, 4, 5, 6} {
+		evt, err := resumed.Recv()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if evt.Seq != seq {
+			t.Fatalf("expected seq %d, got %v", seq, evt)
+		}
+	}
+}
+
+func TestLoggingReplayWithoutJournal(t *testing.T) {
+	ctx, finish := context.WithCancel(context.Background())
+	err := StartMyMicroservice(ctx, listenAddr, ACLData)
+	if err != nil {
+		t.Fatalf("cant start server initial: %v", err)
+	}
+	wait(1)
+	defer func() {
+		finish()
+		wait(1)
+	}()
+
+	conn := getGrpcConn(t)
+	defer conn.Close()
+
+	logStream, err := NewAdminClient(conn).Logging(getConsumerCtx("logger1"), &LogFilter{FromTimestamp: time.Now().Unix()})
+	if err == nil {
+		_, err = logStream.Recv()
+	}
+	if code := grpc.Code(err); code != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition, got %v", err)
+	}
+}
+
+func TestJournalGap(t *testing.T) {
+	j, err := OpenJournal(JournalConfig{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("cant open journal: %v", err)
+	}
+	defer j.Close()
+
+	// 3 and 4 were dropped
+	for _, seq := range []uint64{1, 2, 5, 6} {
+		j.Append(&Event{Seq: seq})
+	}
+	if err := j.WaitWritten(6); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, tc := range []struct {
+		From uint64
+		To   uint64
+		Gap  *JournalGapError
+	}{
+		{1, 2, nil},
+		{5, 6, nil},
+		{1, 6, &JournalGapError{From: 3, To: 4}},
+		{2, 5, &JournalGapError{From: 3, To: 4}},
+		{3, 3, &JournalGapError{From: 3, To: 3}},
+		{5, 8, &JournalGapError{From: 7, To: 8}},
+	} {
+		err := j.Replay(tc.From, 0, tc.To, func(e *Event) error { return nil })
+		if tc.Gap == nil && err != nil || tc.Gap != nil && (err == nil || *err.(*JournalGapError) != *tc.Gap) {
+			t.Errorf("replay %d..%d: expected gap %v, got %v", tc.From, tc.To, tc.Gap, err)
+		}
+	}
+}
+
+func TestJournalSeqAfterCrash(t *testing.T) {
+	cfg := JournalConfig{Dir: t.TempDir(), QueueSize: 4}
+
+	j, err := OpenJournal(cfg)
+	if err != nil {
+		t.Fatalf("cant open journal: %v", err)
+	}
+	subs := newEventSubs(1, DropNewest)
+	subs.useJournal(j)
+	for i := 0; i < 3; i++ {
+		subs.Notify(&Event{Method: "/main.Biz/Check"})
+	}
+	if err := j.Close(); err != nil {
+		t.Fatalf("cant close journal: %v", err)
+	}
+
+	// as if the process died with events still queued, twice in a row
+	// before anything more was written
+	for _, lastSeq := range []uint64{3 + 8, 3 + 8 + 8} {
+		os.WriteFile(filepath.Join(cfg.Dir, journalRunningFile), nil, 0644)
+
+		j, err = OpenJournal(cfg)
+		if err != nil {
+			t.Fatalf("cant reopen journal: %v", err)
+		}
+		if seq := j.LastSeq(); seq != lastSeq {
+			t.Fatalf("expected numbering to continue after %d, got %d", lastSeq, seq)
+		}
+		j.Close()
+	}
+
+	j, err = OpenJournal(cfg)
+	if err != nil {
+		t.Fatalf("cant reopen journal: %v", err)
+	}
+	defer j.Close()
+
+	subs = newEventSubs(1, DropNewest)
+	subs.useJournal(j)
+	subs.Notify(&Event{Method: "/main.Biz/Add"})
+	if err := j.WaitWritten(20); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var gap *JournalGapError
+	err = j.Replay(1, 0, 20, func(e *Event) error { return nil })
+	if !errors.As(err, &gap) || gap.From != 4 || gap.To != 19 {
+		t.Fatalf("expected events 4 to 19 to be missing, got %v", err)
+	}
+	if seqs := replayAll(t, j, 20, 0); len(seqs) != 1 || seqs[0] != 20 {
+		t.Fatalf("expected event 20, got %v", seqs)
+	}
+}
+
+func TestLoggingReplayGap(t *testing.T) {
+	dir := t.TempDir()
+
+	j, err := OpenJournal(JournalConfig{Dir: dir})
+	if err != nil {
+		t.Fatalf("cant open journal: %v", err)
+	}
+	for _, seq := range []uint64{1, 2, 5} {
+		j.Append(&Event{Seq: seq, Method: "/main.Biz/Check"})
+	}
+	j.Close()
+
+	ctx, finish := context.WithCancel(context.Background())
+	err = StartMyMicroservice(ctx, listenAddr, ACLData, WithJournal(JournalConfig{Dir: dir}))
+	if err != nil {
+		t.Fatalf("cant start server initial: %v", err)
+	}
+	wait(1)
+	defer func() {
+		finish()
+		wait(1)
+	}()
+
+	conn := getGrpcConn(t)
+	defer conn.Close()
+	adm := NewAdminClient(conn)
+
+	// what is there is sent, then the client has to go on with live events
+	logStream, err := adm.Logging(getConsumerCtx("logger1"), &LogFilter{FromSeq: 1})
+	seqs := []uint64{}
+	for err == nil {
+		var evt *Event
+		if evt, err = logStream.Recv(); err == nil {
+			seqs = append(seqs, evt.Seq)
+		}
+	}
+	if code := grpc.Code(err); code != codes.FailedPrecondition || len(seqs) != 2 {
+		t.Fatalf("expected events 1 and 2 and FailedPrecondition, got %v and %v", seqs, err)
+	}
+
+	logStream, err = adm.Logging(getConsumerCtx("logger1"), &LogFilter{FromSeq: 5})
+	if err != nil {
+		t.Fatalf("cant open log stream: %v", err)
+	}
+	if evt, err := logStream.Recv(); err != nil || evt.Seq != 5 {
+		t.Fatalf("expected event 5 after the gap, got %v, %v", evt, err)
+	}
+}
@@ -0,0 +1,151 @@
+//go:build unix
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestJournalStalledWriter(t *testing.T) {
+	dir := t.TempDir()
+
+	ctx, finish := context.WithCancel(context.Background())
+	err := StartMyMicroservice(ctx, listenAddr, ACLData, WithJournal(JournalConfig{Dir: dir, QueueSize: 4}))
+	if err != nil {
+		t.Fatalf("cant start server initial: %v", err)
+	}
+	wait(1)
+	defer func() {
+		finish()
+		wait(1)
+	}()
+
+	// the writer hangs opening the first segment until somebody reads it,
+	// like on a stuck disk
+	segment := filepath.Join(dir, fmt.Sprintf("%020d%s", 1, journalExt))
+	if err := syscall.Mkfifo(segment, 0644); err != nil {
+		t.Fatalf("cant make fifo: %v", err)
+	}
+	// opened for reading and writing it does not wait for the writer, and
+	// the pipe buffer takes what the writer has
+	unblock := func() {
+		f, err := os.OpenFile(segment, os.O_RDWR, 0)
+		if err != nil {
+			t.Errorf("cant open fifo: %v", err)
+			return
+		}
+		t.Cleanup(func() { f.Close() })
+	}
+
+	conn := getGrpcConn(t)
+	defer conn.Close()
+	biz := NewBizClient(conn)
+
+	for i := 0; i < 20; i++ {
+		callCtx, cancel := context.WithTimeout(getConsumerCtx("biz_user"), time.Second)
+		_, err := biz.Check(callCtx, &CounterKey{})
+		cancel()
+		if err != nil {
+			unblock()
+			t.Fatalf("[%d] call stalled by the journal: %v", i, err)
+		}
+	}
+
+	unblock()
+}
+
+func TestJournalRestartAfterDrops(t *testing.T) {
+	cfg := JournalConfig{Dir: t.TempDir(), QueueSize: 2}
+
+	j, err := OpenJournal(cfg)
+	if err != nil {
+		t.Fatalf("cant open journal: %v", err)
+	}
+
+	segment := filepath.Join(cfg.Dir, fmt.Sprintf("%020d%s", 1, journalExt))
+	if err := syscall.Mkfifo(segment, 0644); err != nil {
+		t.Fatalf("cant make fifo: %v", err)
+	}
+
+	subs := newEventSubs(1, DropNewest)
+	subs.useJournal(j)
+
+	notified := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			subs.Notify(&Event{Method: "/main.Biz/Check"})
+		}
+		close(notified)
+	}()
+	select {
+	case <-notified:
+	case <-time.After(time.Second):
+		t.Fatalf("Notify blocked on the journal")
+	}
+
+	if j.Dropped() == 0 {
+		t.Fatalf("expected the stalled journal to drop events")
+	}
+
+	// the writer gets going again and settles every event
+	f, err := os.OpenFile(segment, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatalf("cant open fifo: %v", err)
+	}
+	defer f.Close()
+	if err := j.WaitWritten(10); err != nil || j.Err() != nil {
+		t.Fatalf("expected the journal to catch up, got %v and %v", err, j.Err())
+	}
+	if err := j.Close(); err != nil {
+		t.Fatalf("cant close journal: %v", err)
+	}
+
+	// we hold the write end too, so there is no EOF
+	f.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	data, _ := io.ReadAll(f)
+	r := bytes.NewReader(data)
+	var onDisk uint64
+	for {
+		e, _, err := readRecord(r)
+		if err != nil {
+			break
+		}
+		onDisk++
+		if e.Seq != onDisk {
+			t.Fatalf("expected events on disk to go without gaps, got %v", e)
+		}
+	}
+	if onDisk+j.Dropped() != 10 {
+		t.Fatalf("expected every event on disk or dropped, got %d and %d", onDisk, j.Dropped())
+	}
+
+	// the dropped tail was seen by subscribers, its numbers are not reused
+	os.Remove(segment)
+	j, err = OpenJournal(cfg)
+	if err != nil {
+		t.Fatalf("cant reopen journal: %v", err)
+	}
+	defer j.Close()
+
+	if seq := j.LastSeq(); seq != 10 {
+		t.Fatalf("expected numbering to continue after 10, got %d", seq)
+	}
+
+	subs = newEventSubs(1, DropNewest)
+	subs.useJournal(j)
+	subs.Notify(&Event{Method: "/main.Biz/Add"})
+	if err := j.WaitWritten(11); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if seqs := replayAll(t, j, 11, 0); len(seqs) != 1 || seqs[0] != 11 {
+		t.Fatalf("expected event 11, got %v", seqs)
+	}
+}
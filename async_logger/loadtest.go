@@ -0,0 +1,415 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"io"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// loadConfig describes a load test run: workers call Biz as one of
+// consumers in the proportions of mix, while loggers Logging and stats
+// Statistics streams watch.
+type loadConfig struct {
+	addr          string
+	duration      time.Duration
+	consumers     int
+	workers       int
+	mix           []loadCall
+	loggers       int
+	results       bool // loggers get call results too
+	stats         int
+	statInterval  uint64
+	subBufferSize int
+	subPolicy     OverflowPolicy
+}
+
+type loadCall struct {
+	method string
+	weight int
+}
+
+// parseMix reads "check=8,add=2,test=1".
+func parseMix(s string) ([]loadCall, error) {
+	var mix []loadCall
+	total := 0
+	for _, part := range strings.Split(s, ",") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("bad mix %q: expected method=weight", part)
+		}
+
+		var method string
+		switch strings.ToLower(strings.TrimSpace(kv[0])) {
+		case "check":
+			method = "/main.Biz/Check"
+		case "add":
+			method = "/main.Biz/Add"
+		case "test":
+			method = "/main.Biz/Test"
+		default:
+			return nil, fmt.Errorf("bad mix %q: unknown method %q", part, kv[0])
+		}
+
+		weight, err := strconv.Atoi(strings.TrimSpace(kv[1]))
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("bad mix %q: weight must be a non-negative number", part)
+		}
+		mix = append(mix, loadCall{method: method, weight: weight})
+		total += weight
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("bad mix %q: nothing to call", s)
+	}
+
+	return mix, nil
+}
+
+func parseLoadConfig(args []string, stderr io.Writer) (*loadConfig, error) {
+	fs := flag.NewFlagSet("loadtest", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	cfg := &loadConfig{}
+	fs.StringVar(&cfg.addr, "addr", "127.0.0.1:8090", "address for the server under test")
+	fs.DurationVar(&cfg.duration, "duration", 10*time.Second, "how long to keep calling")
+	fs.IntVar(&cfg.consumers, "consumers", 16, "distinct consumers, each with its own connection")
+	fs.IntVar(&cfg.workers, "workers", 64, "concurrent callers spread over the consumers")
+	mix := fs.String("mix", "check=8,add=2,test=1", "Biz methods and their weights")
+	fs.IntVar(&cfg.loggers, "loggers", 4, "Logging subscribers")
+	fs.BoolVar(&cfg.results, "results", false, "Logging subscribers get call results too")
+	fs.IntVar(&cfg.stats, "stats", 2, "Statistics subscribers")
+	fs.Uint64Var(&cfg.statInterval, "stat-interval", 1, "seconds between statistics")
+	fs.IntVar(&cfg.subBufferSize, "buffer", 256, "events a subscriber may lag behind")
+	policy := fs.String("policy", DropOldest.String(), "drop_oldest, drop_newest or disconnect")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	// workers are spread over consumers, there has to be one of each
+	if cfg.consumers <= 0 {
+		return nil, fmt.Errorf("-consumers must be positive, got %d", cfg.consumers)
+	}
+	if cfg.workers <= 0 {
+		return nil, fmt.Errorf("-workers must be positive, got %d", cfg.workers)
+	}
+
+	var err error
+	if cfg.mix, err = parseMix(*mix); err != nil {
+		return nil, err
+	}
+	for _, p := range []OverflowPolicy{DropOldest, DropNewest, Disconnect} {
+		if p.String() == *policy {
+			cfg.subPolicy = p
+			return cfg, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown policy %q", *policy)
+}
+
+// loadReport is what a run has measured. Delivery lag is how long after
+// the worker has started a call its event arrives at a Logging stream.
+type loadReport struct {
+	elapsed time.Duration
+	calls   uint64
+	codes   map[string]uint64
+	latency *latencyHistogram
+
+	loggers  int
+	expected uint64 // events every logger should have got
+	events   uint64 // by all loggers
+	dropped  uint64
+	lag      *latencyHistogram
+
+	stats      int
+	statCalls  uint64 // Biz calls counted by all Statistics streams
+	statUpdate uint64
+}
+
+func loadTestMain(args []string, stdout io.Writer, stderr io.Writer) int {
+	cfg, err := parseLoadConfig(args, stderr)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	report, err := runLoadTest(context.Background(), cfg)
+	if err != nil {
+		fmt.Fprintf(stderr, "load test failed: %v\n", err)
+		return 1
+	}
+	report.Print(stdout)
+
+	return 0
+}
+
+func loadACL(consumers int) string {
+	acl := map[string][]string{"load_admin": {"/main.Admin/*"}}
+	for i := 0; i < consumers; i++ {
+		acl[loadConsumer(i)] = []string{"/main.Biz/*"}
+	}
+	data, _ := json.Marshal(acl)
+
+	return string(data)
+}
+
+func loadConsumer(i int) string {
+	return "load_" + strconv.Itoa(i)
+}
+
+// loadTraceparent puts the time the call has started into the trace id,
+// so that loggers can tell the lag of any event without shared state.
+func loadTraceparent(start time.Time, n uint64) string {
+	return fmt.Sprintf("00-%016x%016x-%016x-00", start.UnixNano(), n, n)
+}
+
+func loadStarted(traceID string) (time.Time, bool) {
+	if len(traceID) != 32 {
+		return time.Time{}, false
+	}
+	nanos, err := strconv.ParseInt(traceID[:16], 16, 64)
+
+	return time.Unix(0, nanos), err == nil
+}
+
+func runLoadTest(ctx context.Context, cfg *loadConfig) (*loadReport, error) {
+	serverCtx, stopServer := context.WithCancel(ctx)
+	defer stopServer()
+
+	// the callers are our own, they name themselves in metadata
+	err := StartMyMicroservice(serverCtx, cfg.addr, loadACL(cfg.consumers),
+		WithAuthenticators(MetadataAuthenticator{}),
+		WithSubscriberBuffer(cfg.subBufferSize, cfg.subPolicy),
+		WithDrainTimeout(time.Second))
+	if err != nil {
+		return nil, err
+	}
+
+	dial := func() (*grpc.ClientConn, error) {
+		dialCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		return grpc.DialContext(dialCtx, cfg.addr, grpc.WithInsecure(), grpc.WithBlock())
+	}
+
+	adminConn, err := dial()
+	if err != nil {
+		return nil, err
+	}
+	defer adminConn.Close()
+
+	conns := make([]*grpc.ClientConn, cfg.consumers)
+	for i := range conns {
+		if conns[i], err = dial(); err != nil {
+			return nil, err
+		}
+		defer conns[i].Close()
+	}
+
+	report := &loadReport{
+		codes:   map[string]uint64{},
+		latency: newLatencyHistogram(),
+		loggers: cfg.loggers,
+		lag:     newLatencyHistogram(),
+		stats:   cfg.stats,
+	}
+
+	watchCtx, stopWatching := context.WithCancel(metadata.AppendToOutgoingContext(ctx, "consumer", "load_admin"))
+	defer stopWatching()
+	watchers := &sync.WaitGroup{}
+	mux := &sync.Mutex{}
+	adm := NewAdminClient(adminConn)
+
+	for i := 0; i < cfg.loggers; i++ {
+		stream, err := adm.Logging(watchCtx, &LogFilter{Consumers: loadConsumers(cfg.consumers), WithResults: cfg.results})
+		if err != nil {
+			return nil, err
+		}
+
+		watchers.Add(1)
+		go func() {
+			defer watchers.Done()
+
+			lag := newLatencyHistogram()
+			for {
+				e, err := stream.Recv()
+				if err != nil {
+					break
+				}
+				atomic.AddUint64(&report.events, 1)
+				atomic.AddUint64(&report.dropped, e.Dropped)
+				if started, ok := loadStarted(e.TraceId); ok && e.Result == nil {
+					lag.Observe(time.Since(started).Microseconds())
+				}
+			}
+
+			mux.Lock()
+			report.lag.Merge(lag)
+			mux.Unlock()
+		}()
+	}
+
+	for i := 0; i < cfg.stats; i++ {
+		stream, err := adm.Statistics(watchCtx, &StatInterval{IntervalSeconds: cfg.statInterval})
+		if err != nil {
+			return nil, err
+		}
+
+		watchers.Add(1)
+		go func() {
+			defer watchers.Done()
+
+			for {
+				stat, err := stream.Recv()
+				if err != nil {
+					return
+				}
+				atomic.AddUint64(&report.statUpdate, 1)
+				for consumer, count := range stat.ByConsumer {
+					if strings.HasPrefix(consumer, "load_") && consumer != "load_admin" {
+						atomic.AddUint64(&report.statCalls, count)
+					}
+				}
+			}
+		}()
+	}
+
+	// subscriptions are made by the handlers, give them a moment
+	time.Sleep(100 * time.Millisecond)
+
+	var seq uint64
+	workers := &sync.WaitGroup{}
+	start := time.Now()
+	deadline := start.Add(cfg.duration)
+	for i := 0; i < cfg.workers; i++ {
+		consumer := i % cfg.consumers
+		biz := NewBizClient(conns[consumer])
+		callCtx := metadata.AppendToOutgoingContext(ctx, "consumer", loadConsumer(consumer))
+		random := rand.New(rand.NewSource(int64(i)))
+
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+
+			latency := newLatencyHistogram()
+			codes := map[string]uint64{}
+			for time.Now().Before(deadline) {
+				method := pickCall(cfg.mix, random)
+				started := time.Now()
+				ctx := metadata.AppendToOutgoingContext(callCtx, traceparentHeader, loadTraceparent(started, atomic.AddUint64(&seq, 1)))
+
+				var err error
+				switch method {
+				case "/main.Biz/Check":
+					_, err = biz.Check(ctx, &CounterKey{Name: "load"})
+				case "/main.Biz/Add":
+					_, err = biz.Add(ctx, &CounterDelta{Name: "load"})
+				case "/main.Biz/Test":
+					_, err = biz.Test(ctx, &CounterBatch{})
+				}
+				latency.Observe(time.Since(started).Microseconds())
+				codes[status.Code(err).String()]++
+			}
+
+			mux.Lock()
+			defer mux.Unlock()
+			report.latency.Merge(latency)
+			for code, n := range codes {
+				report.codes[code] += n
+			}
+		}()
+	}
+	workers.Wait()
+	report.elapsed = time.Since(start)
+	report.calls = report.latency.count
+
+	// let the subscribers catch up before hanging up on them
+	report.expected = report.calls
+	if cfg.results {
+		report.expected *= 2
+	}
+	settle := time.Now().Add(time.Duration(cfg.statInterval)*time.Second + time.Second)
+	for time.Now().Before(settle) {
+		if atomic.LoadUint64(&report.events)+atomic.LoadUint64(&report.dropped) >= report.expected*uint64(cfg.loggers) &&
+			atomic.LoadUint64(&report.statCalls) >= report.calls*uint64(cfg.stats) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	stopWatching()
+	watchers.Wait()
+
+	return report, nil
+}
+
+func loadConsumers(n int) []string {
+	consumers := make([]string, n)
+	for i := range consumers {
+		consumers[i] = loadConsumer(i)
+	}
+
+	return consumers
+}
+
+func pickCall(mix []loadCall, random *rand.Rand) string {
+	total := 0
+	for _, c := range mix {
+		total += c.weight
+	}
+
+	n := random.Intn(total)
+	for _, c := range mix {
+		if n < c.weight {
+			return c.method
+		}
+		n -= c.weight
+	}
+
+	return mix[len(mix)-1].method
+}
+
+func (r *loadReport) Print(w io.Writer) {
+	ms := func(d time.Duration) string {
+		return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 2, 64) + "ms"
+	}
+	quantiles := func(h *latencyHistogram) string {
+		if h.count == 0 {
+			return "-"
+		}
+		return fmt.Sprintf("p50 %s  p90 %s  p99 %s  mean %s", ms(h.Quantile(0.5)), ms(h.Quantile(0.9)), ms(h.Quantile(0.99)),
+			ms(time.Duration(h.sum/int64(h.count))*time.Microsecond))
+	}
+
+	fmt.Fprintf(w, "calls       %d in %s, %.0f/s\n", r.calls, r.elapsed.Round(time.Millisecond), float64(r.calls)/r.elapsed.Seconds())
+
+	codes := make([]string, 0, len(r.codes))
+	for code := range r.codes {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for i, code := range codes {
+		codes[i] = fmt.Sprintf("%s %d", code, r.codes[code])
+	}
+	fmt.Fprintf(w, "codes       %s\n", strings.Join(codes, ", "))
+	fmt.Fprintf(w, "latency     %s\n", quantiles(r.latency))
+
+	if r.loggers > 0 {
+		fmt.Fprintf(w, "logging     %d subscribers, %d of %d events each on average, %d dropped\n",
+			r.loggers, r.events/uint64(r.loggers), r.expected, r.dropped)
+		fmt.Fprintf(w, "lag         %s\n", quantiles(r.lag))
+	}
+	if r.stats > 0 {
+		fmt.Fprintf(w, "statistics  %d subscribers, %d updates, %d of %d calls counted each on average\n",
+			r.stats, r.statUpdate, r.statCalls/uint64(r.stats), r.calls)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestParseLoadConfig(t *testing.T) {
+	cfg, err := parseLoadConfig([]string{"-mix", "check=1, add=0", "-policy", "disconnect"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.mix) != 2 || cfg.mix[0].method != "/main.Biz/Check" || cfg.subPolicy != Disconnect {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+
+	for idx, tc := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"-mix", "check"}, "expected method=weight"},
+		{[]string{"-mix", "check=1,delete=1"}, `unknown method "delete"`},
+		{[]string{"-mix", "check=-1"}, "non-negative"},
+		{[]string{"-mix", "check=0"}, "nothing to call"},
+		{[]string{"-policy", "block"}, `unknown policy "block"`},
+		{[]string{"-consumers", "0"}, "-consumers must be positive"},
+		{[]string{"-workers", "-1"}, "-workers must be positive"},
+	} {
+		_, err := parseLoadConfig(tc.args, &bytes.Buffer{})
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("[%d] expected error with %q, got %v", idx, tc.err, err)
+		}
+	}
+
+	// a bad flag is a usage error, nothing is run
+	stderr := &bytes.Buffer{}
+	if code := loadTestMain([]string{"-consumers", "0"}, &bytes.Buffer{}, stderr); code != 2 || !strings.Contains(stderr.String(), "-consumers") {
+		t.Fatalf("expected exit code 2 with an explanation, got %d %q", code, stderr)
+	}
+}
+
+func TestLoadTest(t *testing.T) {
+	cfg, err := parseLoadConfig([]string{
+		"-addr", listenAddr, "-duration", "300ms", "-consumers", "2", "-workers", "4",
+		"-loggers", "2", "-results", "-stats", "1", "-buffer", "100000",
+	}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	report, err := runLoadTest(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("load test failed: %v", err)
+	}
+	// the server stops in the background
+	defer wait(20)
+
+	if report.calls == 0 || report.codes["OK"] != report.calls {
+		t.Fatalf("expected only successful calls, got %d: %v", report.calls, report.codes)
+	}
+	// nothing may be lost with buffers this large
+	if report.dropped != 0 || report.events != 2*report.expected || report.expected != 2*report.calls {
+		t.Fatalf("expected every logger to get %d events, got %d in total and %d dropped", report.expected, report.events, report.dropped)
+	}
+	if report.lag.count != 2*report.calls {
+		t.Fatalf("expected a lag for every call event, got %d", report.lag.count)
+	}
+	if report.statCalls != report.calls || report.statUpdate == 0 {
+		t.Fatalf("expected statistics to count %d calls, got %d in %d updates", report.calls, report.statCalls, report.statUpdate)
+	}
+
+	out := &bytes.Buffer{}
+	report.Print(out)
+	for _, prefix := range []string{"calls ", "codes       OK ", "latency     p50 ", "logging     2 subscribers", "lag ", "statistics  1 subscribers"} {
+		if !strings.Contains(out.String(), "\n"+prefix) && !strings.HasPrefix(out.String(), prefix) {
+			t.Fatalf("expected a line starting with %q in\n%s", prefix, out)
+		}
+	}
+}
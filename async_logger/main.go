@@ -1,5 +1,11 @@
 package main
 
+import "os"
+
 func main() {
-	println("usage: make test")
+	if len(os.Args) > 1 && os.Args[1] == "loadtest" {
+		os.Exit(loadTestMain(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
+	println("usage: make test, or go run . loadtest -h")
 }
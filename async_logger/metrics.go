@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// serverMetrics keeps server-wide totals since start and exposes them in
+// the Prometheus text format.
+type serverMetrics struct {
+	mux       *sync.Mutex
+	stats     *StatisticsCollector
+	denials   map[string]uint64 // by consumer
+	throttled map[string]uint64 // by consumer
+	subs      *EventSubs
+
+	accessLogs []*bufferedSink
+	journal    *Journal
+}
+
+func newServerMetrics(subs *EventSubs) *serverMetrics {
+	m := &serverMetrics{
+		mux:       &sync.Mutex{},
+		stats:     newStatisticsCollector(),
+		denials:   map[string]uint64{},
+		throttled: map[string]uint64{},
+		subs:      subs,
+	}
+	subs.Observe(m.update)
+
+	return m
+}
+
+func (m *serverMetrics) update(e *Event) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	m.stats.Update(e)
+	if e.Result != nil || e.Alert != nil || !e.Denied {
+		return
+	}
+	if e.Throttled {
+		m.throttled[e.Consumer]++
+	} else {
+		m.denials[e.Consumer]++
+	}
+}
+
+// Snapshot returns the totals in the form of Admin.Statistics.
+func (m *serverMetrics) Snapshot() *Stat {
+	_, dropped := m.subs.Stats()
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	stat := m.stats.Snapshot()
+	stat.Dropped = dropped
+
+	return stat
+}
+
+func (m *serverMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	bw := bufio.NewWriter(w)
+	m.write(bw)
+	bw.Flush()
+}
+
+func (m *serverMetrics) write(w *bufio.Writer) {
+	subscribers, dropped := m.subs.Stats()
+
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	writeCounters(w, "async_logger_calls_by_method_total", "Calls by method.", "method", m.stats.stat.ByMethod)
+	writeCounters(w, "async_logger_calls_by_consumer_total", "Calls by consumer.", "consumer", m.stats.stat.ByConsumer)
+	writeCounters(w, "async_logger_responses_by_code_total", "Finished calls by gRPC status code.", "code", m.stats.stat.ByCode)
+	writeCounters(w, "async_logger_errors_by_method_total", "Finished calls with a status other than OK.", "method", m.stats.stat.ErrorsByMethod)
+	writeCounters(w, "async_logger_acl_denials_total", "Calls rejected by authentication or ACL.", "consumer", m.denials)
+	writeCounters(w, "async_logger_throttled_total", "Calls rejected by rate limits or quotas.", "consumer", m.throttled)
+
+	fmt.Fprintf(w, "# HELP async_logger_call_duration_seconds Call latency measured around the handler.\n")
+	fmt.Fprintf(w, "# TYPE async_logger_call_duration_seconds histogram\n")
+	methods := make([]string, 0, len(m.stats.latency))
+	for method := range m.stats.latency {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	for _, method := range methods {
+		h := m.stats.latency[method]
+		label := "method=" + quoteLabel(method)
+
+		var cumulative uint64
+		for i, bound := range h.bounds {
+			cumulative += h.buckets[i]
+			fmt.Fprintf(w, "async_logger_call_duration_seconds_bucket{%s,le=%q} %d\n", label, seconds(bound), cumulative)
+		}
+		fmt.Fprintf(w, "async_logger_call_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", label, h.count)
+		fmt.Fprintf(w, "async_logger_call_duration_seconds_sum{%s} %s\n", label, seconds(h.sum))
+		fmt.Fprintf(w, "async_logger_call_duration_seconds_count{%s} %d\n", label, h.count)
+	}
+
+	fmt.Fprintf(w, "# HELP async_logger_subscribers Connected Logging and Statistics streams.\n")
+	fmt.Fprintf(w, "# TYPE async_logger_subscribers gauge\n")
+	fmt.Fprintf(w, "async_logger_subscribers %d\n", subscribers)
+
+	fmt.Fprintf(w, "# HELP async_logger_dropped_events_total Events lost by slow subscribers.\n")
+	fmt.Fprintf(w, "# TYPE async_logger_dropped_events_total counter\n")
+	fmt.Fprintf(w, "async_logger_dropped_events_total %d\n", dropped)
+
+	if len(m.accessLogs) > 0 {
+		sinkDropped := map[string]uint64{}
+		sinkFailed := map[string]uint64{}
+		sinkSpilled := map[string]uint64{}
+		for _, sink := range m.accessLogs {
+			sinkDropped[sink.name], sinkFailed[sink.name] = sink.Stats()
+			sinkSpilled[sink.name] = sink.Spilled()
+		}
+		writeCounters(w, "async_logger_access_log_dropped_total", "Events not written because the spill file failed.", "sink", sinkDropped)
+		writeCounters(w, "async_logger_access_log_spilled_total", "Events spilled to disk because the sink fell behind.", "sink", sinkSpilled)
+		writeCounters(w, "async_logger_access_log_errors_total", "Failed writes to the sink.", "sink", sinkFailed)
+	}
+
+	if m.journal != nil {
+		failed := 0
+		if m.journal.Err() != nil {
+			failed = 1
+		}
+		fmt.Fprintf(w, "# HELP async_logger_journal_failed Whether the last journal write failed.\n")
+		fmt.Fprintf(w, "# TYPE async_logger_journal_failed gauge\n")
+		fmt.Fprintf(w, "async_logger_journal_failed %d\n", failed)
+
+		fmt.Fprintf(w, "# HELP async_logger_journal_dropped_events_total Events not written to the journal.\n")
+		fmt.Fprintf(w, "# TYPE async_logger_journal_dropped_events_total counter\n")
+		fmt.Fprintf(w, "async_logger_journal_dropped_events_total %d\n", m.journal.Dropped())
+	}
+}
+
+func writeCounters(w *bufio.Writer, name string, help string, label string, values map[string]uint64) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s counter\n", name)
+	for _, key := range sortedKeys(values) {
+		fmt.Fprintf(w, "%s{%s=%s} %d\n", name, label, quoteLabel(key), values[key])
+	}
+}
+
+func sortedKeys(m map[string]uint64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func quoteLabel(value string) string {
+	b := strings.Builder{}
+	b.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\n':
+			b.WriteString(`\n`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+
+	return b.String()
+}
+
+func seconds(micros int64) string {
+	return strconv.FormatFloat(float64(micros)/1e6, 'g', -1, 64)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const metricsAddr string = "127.0.0.1:8083"
+
+func TestMetrics(t *testing.T) {
+	ctx, finish := context.WithCancel(context.Background())
+	err := StartMyMicroservice(ctx, listenAddr, ACLData, WithMetricsListener(metricsAddr))
+	if err != nil {
+		t.Fatalf("cant start server initial: %v", err)
+	}
+	wait(1)
+	defer func() {
+		finish()
+		wait(1)
+	}()
+
+	conn := getGrpcConn(t)
+	defer conn.Close()
+
+	biz := NewBizClient(conn)
+	adm := NewAdminClient(conn)
+
+	if _, err := adm.Logging(getConsumerCtx("logger1"), &LogFilter{}); err != nil {
+		t.Fatalf("cant open log stream: %v", err)
+	}
+	wait(1)
+
+	biz.Check(getConsumerCtx("biz_user"), &CounterKey{})
+	biz.Check(getConsumerCtx("biz_user"), &CounterKey{})
+	biz.Test(getConsumerCtx("biz_user"), &CounterBatch{})
+	biz.Test(context.Background(), &CounterBatch{})
+	wait(1)
+
+	resp, err := http.Get("http://" + metricsAddr + "/metrics")
+	if err != nil {
+		t.Fatalf("cant get metrics: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	for _, line := range []string{
+		`# TYPE async_logger_calls_by_method_total counter`,
+		`async_logger_calls_by_method_total{method="/main.Biz/Check"} 2`,
+		`async_logger_calls_by_method_total{method="/main.Biz/Test"} 2`,
+		`async_logger_calls_by_consumer_total{consumer="biz_user"} 3`,
+		`async_logger_responses_by_code_total{code="OK"} 2`,
+		`async_logger_responses_by_code_total{code="Unauthenticated"} 2`,
+		`async_logger_errors_by_method_total{method="/main.Biz/Test"} 2`,
+		`async_logger_acl_denials_total{consumer=""} 1`,
+		`async_logger_acl_denials_total{consumer="biz_user"} 1`,
+		`async_logger_call_duration_seconds_bucket{method="/main.Biz/Check",le="+Inf"} 2`,
+		`async_logger_call_duration_seconds_count{method="/main.Biz/Check"} 2`,
+		`async_logger_subscribers 1`,
+		`async_logger_dropped_events_total 0`,
+	} {
+		if !strings.Contains(string(body), line+"\n") {
+			t.Fatalf("expected %q in metrics:\n%s", line, body)
+		}
+	}
+}
+
+func TestQuoteLabel(t *testing.T) {
+	if have := quoteLabel("a\"b\\c\nd"); have != `"a\"b\\c\nd"` {
+		t.Fatalf("bad escaping: %s", have)
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// limitConfig is a token bucket refilled with Rate tokens per second up to
+// Burst, plus a number of calls allowed per UTC day. Zero disables either.
+type limitConfig struct {
+	Rate       float64 `json:"rate"`
+	Burst      float64 `json:"burst"`
+	DailyQuota uint64  `json:"daily_quota"`
+}
+
+func (lc *limitConfig) validate() error {
+	if lc.Rate < 0 || lc.Burst < 0 {
+		return fmt.Errorf("rate and burst must not be negative")
+	}
+	if lc.Rate > 0 && lc.Burst == 0 {
+		lc.Burst = lc.Rate
+		if lc.Burst < 1 {
+			lc.Burst = 1
+		}
+	}
+
+	return nil
+}
+
+// consumerLimits is the "limits" object of a consumer in the ACL document.
+// Methods are keyed by the same patterns and @groups ACL rules use. When
+// several keys match a call the longest pattern applies, a pattern written
+// as is wins over the same one coming from a group. The method limit
+// applies on top of the consumer-wide one.
+type consumerLimits struct {
+	limitConfig
+	Methods map[string]*limitConfig `json:"methods"`
+
+	patterns []methodLimit
+}
+
+type methodLimit struct {
+	key   string
+	rule  aclRule
+	limit *limitConfig
+}
+
+func (cl *consumerLimits) compile(p *aclParser, consumer string) error {
+	if err := cl.limitConfig.validate(); err != nil {
+		return fmt.Errorf("limits of consumer %s: %v", consumer, err)
+	}
+
+	for key, lc := range cl.Methods {
+		if lc == nil {
+			return fmt.Errorf("limits of consumer %s: no limit for %s", consumer, key)
+		}
+		if err := lc.validate(); err != nil {
+			return fmt.Errorf("limits of consumer %s for %s: %v", consumer, key, err)
+		}
+
+		rules, err := p.expand(consumer, key)
+		if err != nil {
+			return err
+		}
+		for _, rule := range rules {
+			cl.patterns = append(cl.patterns, methodLimit{key: key, rule: rule, limit: lc})
+		}
+	}
+
+	sort.Slice(cl.patterns, func(i, j int) bool {
+		a, b := cl.patterns[i], cl.patterns[j]
+		if len(a.rule.pattern) != len(b.rule.pattern) {
+			return len(a.rule.pattern) > len(b.rule.pattern)
+		}
+		if (a.rule.group == "") != (b.rule.group == "") {
+			return a.rule.group == ""
+		}
+		return a.key < b.key
+	})
+
+	return nil
+}
+
+func (cl *consumerLimits) forMethod(method string) (string, *limitConfig) {
+	methodParts := strings.Split(method, "/")
+	for _, ml := range cl.patterns {
+		if ml.rule.matches(methodParts) {
+			return ml.key, ml.limit
+		}
+	}
+
+	return "", nil
+}
+
+type limitState struct {
+	tokens float64
+	last   time.Time
+	day    string
+	used   uint64
+}
+
+func (ls *limitState) check(lc *limitConfig, now time.Time) string {
+	if lc.Rate > 0 {
+		if ls.last.IsZero() {
+			ls.tokens = lc.Burst
+		} else {
+			ls.tokens += now.Sub(ls.last).Seconds() * lc.Rate
+		}
+		if ls.tokens > lc.Burst {
+			ls.tokens = lc.Burst
+		}
+		ls.last = now
+
+		if ls.tokens < 1 {
+			return fmt.Sprintf("rate limit of %v/s exceeded", lc.Rate)
+		}
+	}
+
+	if lc.DailyQuota > 0 {
+		if day := now.UTC().Format("2006-01-02"); day != ls.day {
+			ls.day = day
+			ls.used = 0
+		}
+
+		if ls.used >= lc.DailyQuota {
+			return fmt.Sprintf("daily quota of %d calls exhausted", lc.DailyQuota)
+		}
+	}
+
+	return ""
+}
+
+func (ls *limitState) take(lc *limitConfig) {
+	if lc.Rate > 0 {
+		ls.tokens--
+	}
+	if lc.DailyQuota > 0 {
+		ls.used++
+	}
+}
+
+// rateLimiter keeps bucket and quota state across ACL reloads, only the
+// limits themselves come from the live ACL.
+type rateLimiter struct {
+	mux    *sync.Mutex
+	now    func() time.Time
+	states map[string]*limitState
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		mux:    &sync.Mutex{},
+		now:    time.Now,
+		states: map[string]*limitState{},
+	}
+}
+
+func (rl *rateLimiter) state(key string) *limitState {
+	ls, ok := rl.states[key]
+	if !ok {
+		ls = &limitState{}
+		rl.states[key] = ls
+	}
+
+	return ls
+}
+
+// Allow takes a call from the budget of consumer, or explains why it can't.
+// Nothing is taken when any of the applicable limits is exhausted.
+func (rl *rateLimiter) Allow(consumer string, method string, limits *consumerLimits) (bool, string) {
+	if limits == nil {
+		return true, ""
+	}
+
+	rl.mux.Lock()
+	defer rl.mux.Unlock()
+
+	now := rl.now()
+
+	consumerState := rl.state(consumer)
+	if reason := consumerState.check(&limits.limitConfig, now); reason != "" {
+		return false, fmt.Sprintf("consumer %s: %s", consumer, reason)
+	}
+
+	pattern, methodLimit := limits.forMethod(method)
+	var methodState *limitState
+	if methodLimit != nil {
+		methodState = rl.state(consumer + "\x00" + pattern)
+		if reason := methodState.check(methodLimit, now); reason != "" {
+			return false, fmt.Sprintf("consumer %s on %s: %s", consumer, pattern, reason)
+		}
+	}
+
+	consumerState.take(&limits.limitConfig)
+	if methodState != nil {
+		methodState.take(methodLimit)
+	}
+
+	return true, ""
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+const limitedACLData string = `{
+	"groups": {
+		"writes": ["/main.Biz/Add", "/main.Biz/Test"]
+	},
+	"consumers": {
+		"logger1": ["/main.Admin/Logging"],
+		"noisy": {
+			"allow": ["/main.Biz/*"],
+			"limits": {
+				"rate": 1, "burst": 3,
+				"methods": {
+					"@writes":        {"daily_quota": 2},
+					"/main.Biz/Test": {"rate": 0.5, "burst": 1}
+				}
+			}
+		},
+		"quiet": ["/main.Biz/*"]
+	}
+}`
+
+func TestRateLimiter(t *testing.T) {
+	auth, err := newAclAuth(limitedACLData)
+	if err != nil {
+		t.Fatalf("cant parse acl: %v", err)
+	}
+	limits := auth.limits["noisy"]
+	if limits == nil || auth.limits["quiet"] != nil {
+		t.Fatalf("limits are not parsed: %v", auth.limits)
+	}
+
+	now := time.Date(2026, 1, 1, 23, 59, 0, 0, time.UTC)
+	rl := newRateLimiter()
+	rl.now = func() time.Time { return now }
+
+	allow := func(method string) string {
+		_, reason := rl.Allow("noisy", method, limits)
+		return reason
+	}
+
+	// burst of 3 for the consumer
+	for i := 0; i < 3; i++ {
+		if reason := allow("/main.Biz/Check"); reason != "" {
+			t.Fatalf("[%d] unexpected rejection: %s", i, reason)
+		}
+	}
+	if reason := allow("/main.Biz/Check"); !strings.Contains(reason, "rate limit of 1/s exceeded") {
+		t.Fatalf("expected rate limit, got %q", reason)
+	}
+
+	// refilled after 3 seconds, the longest matching key applies to Test
+	now = now.Add(3 * time.Second)
+	if reason := allow("/main.Biz/Test"); reason != "" {
+		t.Fatalf("unexpected rejection: %s", reason)
+	}
+	if reason := allow("/main.Biz/Test"); !strings.Contains(reason, "on /main.Biz/Test: rate limit of 0.5/s exceeded") {
+		t.Fatalf("expected method rate limit, got %q", reason)
+	}
+
+	// a rejected call does not take anything, so two Adds still fit the quota
+	for i := 0; i < 2; i++ {
+		if reason := allow("/main.Biz/Add"); reason != "" {
+			t.Fatalf("[%d] unexpected rejection: %s", i, reason)
+		}
+	}
+	now = now.Add(10 * time.Second)
+	if reason := allow("/main.Biz/Add"); !strings.Contains(reason, "on @writes: daily quota of 2 calls exhausted") {
+		t.Fatalf("expected quota, got %q", reason)
+	}
+
+	// quotas start over on the next UTC day
+	now = now.Add(time.Minute)
+	if reason := allow("/main.Biz/Add"); reason != "" {
+		t.Fatalf("expected new quota on the next day, got %q", reason)
+	}
+
+	if ok, _ := rl.Allow("quiet", "/main.Biz/Add", auth.limits["quiet"]); !ok {
+		t.Fatalf("consumer without limits was throttled")
+	}
+}
+
+func TestRateLimitErrors(t *testing.T) {
+	for idx, tc := range []struct {
+		limits string
+		err    string
+	}{
+		{`{"rate": -1}`, "must not be negative"},
+		{`{"methods": {"/main.Biz/Add": {"burst": -1}}}`, "must not be negative"},
+		{`{"methods": {"main.Biz/Add": {"rate": 1}}}`, "bad method"},
+		{`{"methods": {"@missing": {"rate": 1}}}`, "unknown group @missing"},
+		{`{"methods": {"/main.Biz/Add": null}}`, "no limit for /main.Biz/Add"},
+	} {
+		_, err := newAclAuth(`{"consumers": {"a": {"allow": ["/*"], "limits": ` + tc.limits + `}}}`)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("[%d] expected error with %q, got %v", idx, tc.err, err)
+		}
+	}
+}
+
+func TestThrottling(t *testing.T) {
+	ctx, finish := context.WithCancel(context.Background())
+	err := StartMyMicroservice(ctx, listenAddr, limitedACLData)
+	if err != nil {
+		t.Fatalf("cant start server initial: %v", err)
+	}
+	wait(1)
+	defer func() {
+		finish()
+		wait(1)
+	}()
+
+	conn := getGrpcConn(t)
+	defer conn.Close()
+
+	biz := NewBizClient(conn)
+	adm := NewAdminClient(conn)
+
+	logStream, err := adm.Logging(getConsumerCtx("logger1"), &LogFilter{Consumers: []string{"noisy"}, WithResults: true})
+	if err != nil {
+		t.Fatalf("cant open log stream: %v", err)
+	}
+	wait(1)
+
+	for i := 0; i < 3; i++ {
+		if _, err := biz.Check(getConsumerCtx("noisy"), &CounterKey{}); err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+	}
+	_, err = biz.Check(getConsumerCtx("noisy"), &CounterKey{})
+	if code := grpc.Code(err); code != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := biz.Check(getConsumerCtx("quiet"), &CounterKey{}); err != nil {
+			t.Fatalf("[%d] unexpected error: %v", i, err)
+		}
+	}
+
+	// call and result events for three allowed calls, then the throttled one
+	for i := 0; i < 6; i++ {
+		if _, err := logStream.Recv(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	evt, err := logStream.Recv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !evt.Denied || !evt.Throttled || !strings.Contains(evt.Reason, "rate limit") {
+		t.Fatalf("expected throttled event, got %v", evt)
+	}
+	evt, err = logStream.Recv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if evt.Result.GetCode() != codes.ResourceExhausted.String() {
+		t.Fatalf("expected ResourceExhausted result, got %v", evt)
+	}
+}
@@ -2,16 +2,23 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"coursera/hw7_microservice/internal/counts"
+	"errors"
 	"fmt"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"net"
+	"net/http"
+	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -19,126 +26,473 @@ import (
 // обращаю ваше внимание - в этом задании запрещены глобальные переменные
 type BizServerImpl struct {
 	UnimplementedBizServer
+
+	storage CounterStorage
 }
 
-func (s *BizServerImpl) Check(context.Context, *Nothing) (*Nothing, error) {
-	return &Nothing{}, nil
+func (s *BizServerImpl) Check(ctx context.Context, key *CounterKey) (*Counter, error) {
+	values, err := s.storage.Get(key.Name)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cant read counter: %v", err)
+	}
+
+	return &Counter{Name: key.Name, Value: values[0]}, nil
 }
 
-func (s *BizServerImpl) Add(context.Context, *Nothing) (*Nothing, error) {
-	return &Nothing{}, nil
+func (s *BizServerImpl) Add(ctx context.Context, d *CounterDelta) (*Counter, error) {
+	delta := d.Delta
+	if delta == 0 {
+		delta = 1
+	}
+
+	value, err := s.storage.Add(d.Name, delta)
+	if err == errCounterOverflow {
+		return nil, status.Errorf(codes.OutOfRange, "counter %q would overflow", d.Name)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cant update counter: %v", err)
+	}
+
+	return &Counter{Name: d.Name, Value: value}, nil
 }
 
-func (s *BizServerImpl) Test(context.Context, *Nothing) (*Nothing, error) {
-	return &Nothing{}, nil
+// Test compares the counters with the expected values, all of them read
+// at the same moment.
+func (s *BizServerImpl) Test(ctx context.Context, b *CounterBatch) (*BatchResult, error) {
+	names := make([]string, len(b.Expected))
+	for i, c := range b.Expected {
+		names[i] = c.Name
+	}
+
+	values, err := s.storage.Get(names...)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cant read counters: %v", err)
+	}
+
+	result := &BatchResult{Ok: true}
+	for i, c := range b.Expected {
+		if values[i] != c.Value {
+			result.Ok = false
+			result.Mismatched = append(result.Mismatched, &Counter{Name: c.Name, Value: values[i]})
+		}
+	}
+
+	return result, nil
 }
 
-func NewBizServer() *BizServerImpl {
-	return &BizServerImpl{}
+func NewBizServer(storage CounterStorage) *BizServerImpl {
+	return &BizServerImpl{storage: storage}
 }
 
 type AdminServerImpl struct {
 	UnimplementedAdminServer
 
-	subs *EventSubs
+	subs   *EventSubs
+	acl    *aclStore
+	totals *serverMetrics
 }
 
-func (s *AdminServerImpl) Logging(_ *Nothing, srv Admin_LoggingServer) error {
-	id, events := s.subs.NewSub()
-	defer s.subs.RemoveSub(id)
+func (s *AdminServerImpl) Logging(f *LogFilter, srv Admin_LoggingServer) error {
+	filter, err := newEventFilter(f)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "bad filter: %v", err)
+	}
+	filter = filter.within(s.scope(srv.Context()))
 
-	for e := range events {
-		if err := srv.Send(e); err != nil {
+	var sub *Subscription
+	if f.GetFromSeq() > 0 || f.GetFromTimestamp() > 0 {
+		sub, err = s.subs.NewSubFrom(f.FromSeq, f.FromTimestamp, filter, srv.Send)
+		if err != nil {
 			return err
 		}
+	} else {
+		sub = s.subs.NewSub(filter)
 	}
+	defer s.subs.RemoveSub(sub.ID)
 
-	return nil
+	for e := range sub.Events {
+		if err := srv.Send(withDropped(e, sub.TakeDropped())); err != nil {
+			return err
+		}
+	}
+
+	return sub.Err()
+}
+
+// withDropped tells the subscriber how many events it has missed before e.
+// Events are shared between subscribers, so e itself is left alone.
+func withDropped(e *Event, dropped uint64) *Event {
+	if dropped == 0 {
+		return e
+	}
+
+	e = proto.Clone(e).(*Event)
+	e.Dropped = dropped
+
+	return e
 }
 
 func (s *AdminServerImpl) Statistics(si *StatInterval, srv Admin_StatisticsServer) error {
-	id, events := s.subs.NewSub()
-	defer s.subs.RemoveSub(id)
+	if si.IntervalSeconds == 0 {
+		return status.Errorf(codes.InvalidArgument, "interval_seconds must be positive")
+	}
+	scope := s.scope(srv.Context())
+
+	var update func(e *Event)
+	var collect func() *Stat
+	switch si.Mode {
+	case StatInterval_INTERVAL:
+		statistics := newStatisticsCollector()
+		update, collect = statistics.Update, statistics.Collect
+	case StatInterval_CUMULATIVE:
+		statistics := newStatisticsCollector()
+		update, collect = statistics.Update, statistics.Snapshot
+	case StatInterval_WINDOW:
+		if si.WindowSeconds == 0 {
+			return status.Errorf(codes.InvalidArgument, "window_seconds must be positive")
+		}
+		window := newStatWindow(time.Duration(si.WindowSeconds) * time.Second)
+		update, collect = window.Update, window.Collect
+	case StatInterval_SERVER:
+		if !scope.all {
+			return status.Errorf(codes.PermissionDenied, "server statistics span all tenants")
+		}
+		// counted by the server already, the subscription only tells
+		// when to stop
+		update, collect = func(*Event) {}, s.totals.Snapshot
+	default:
+		return status.Errorf(codes.InvalidArgument, "unknown mode %d", si.Mode)
+	}
 
-	statistics := newStatisticsCollector()
+	sub := s.subs.NewSub((&eventFilter{results: true}).within(scope))
+	defer s.subs.RemoveSub(sub.ID)
 
 	t := time.NewTicker(time.Duration(si.IntervalSeconds) * time.Second)
 	defer t.Stop()
 
+	var dropped uint64
 	for {
 		select {
-		case e, ok := <-events:
+		case e, ok := <-sub.Events:
 			if ok {
-				statistics.Update(e)
+				update(e)
 			} else {
-				return nil
+				return sub.Err()
 			}
 		case <-t.C:
-			if err := srv.Send(statistics.Collect()); err != nil {
+			stat := collect()
+			switch si.Mode {
+			case StatInterval_CUMULATIVE:
+				dropped += sub.TakeDropped()
+				stat.Dropped = dropped
+			case StatInterval_SERVER:
+				// filled in by the snapshot
+			default:
+				stat.Dropped = sub.TakeDropped()
+			}
+			if err := srv.Send(stat); err != nil {
 				return err
 			}
 		}
 	}
 }
 
-func NewAdminServer(s *EventSubs) *AdminServerImpl {
-	return &AdminServerImpl{subs: s}
+func (s *AdminServerImpl) UpdateACL(ctx context.Context, doc *AclDocument) (*AclChange, error) {
+	host := ""
+	if p, ok := peer.FromContext(ctx); ok {
+		host = p.Addr.String()
+	}
+
+	if !s.scope(ctx).all {
+		return nil, status.Errorf(codes.PermissionDenied, "the ACL is shared by all tenants")
+	}
+
+	change, err := s.acl.Update(doc.Json, "rpc", consumerFromContext(ctx), host)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "acl rejected: %v", err)
+	}
+
+	return change, nil
+}
+
+// scope tells which tenants the caller of an Admin method may see.
+func (s *AdminServerImpl) scope(ctx context.Context) tenantScope {
+	if s.acl == nil {
+		return tenantScope{all: true}
+	}
+
+	return s.acl.Load().scope(consumerFromContext(ctx))
+}
+
+func NewAdminServer(s *EventSubs, acl *aclStore, totals *serverMetrics) *AdminServerImpl {
+	return &AdminServerImpl{subs: s, acl: acl, totals: totals}
+}
+
+type OverflowPolicy int
+
+const (
+	DropOldest OverflowPolicy = iota
+	DropNewest
+	Disconnect
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case DropOldest:
+		return "drop_oldest"
+	case DropNewest:
+		return "drop_newest"
+	case Disconnect:
+		return "disconnect"
+	}
+
+	return fmt.Sprintf("OverflowPolicy(%d)", int(p))
+}
+
+type Subscription struct {
+	ID     int
+	Events <-chan *Event
+	// sequence number of the last event published before the subscription
+	StartSeq uint64
+
+	events  chan *Event
+	filter  *eventFilter
+	policy  OverflowPolicy
+	dropped uint64
+	err     error
+}
+
+// TakeDropped returns the number of events lost since the previous call.
+func (s *Subscription) TakeDropped() uint64 {
+	return atomic.SwapUint64(&s.dropped, 0)
+}
+
+// Err reports why the server closed Events; nil means a regular shutdown.
+// It is only meaningful after Events has been closed.
+func (s *Subscription) Err() error {
+	return s.err
 }
 
 type EventSubs struct {
-	id   int
-	subs map[int]chan *Event
-	mux  *sync.Mutex
+	id         int
+	seq        uint64
+	subs       map[int]*Subscription
+	mux        *sync.Mutex
+	bufferSize int
+	policy     OverflowPolicy
+	journal    *Journal
+	observers  []func(*Event)
+	dropped    uint64
+	closed     bool
+	closeErr   error
 }
 
-func newEventSubs() *EventSubs {
+func newEventSubs(bufferSize int, policy OverflowPolicy) *EventSubs {
 	return &EventSubs{
-		subs: map[int]chan *Event{},
-		mux:  &sync.Mutex{},
+		subs:       map[int]*Subscription{},
+		mux:        &sync.Mutex{},
+		bufferSize: bufferSize,
+		policy:     policy,
 	}
 }
 
-func (es *EventSubs) NewSub() (int, chan *Event) {
+// NewSub subscribes to events matching filter, nil means all events.
+func (es *EventSubs) NewSub(filter *eventFilter) *Subscription {
+	return es.newSub(filter, es.policy)
+}
+
+// newSub is NewSub with an overflow policy of its own, for subscriptions
+// that are not a stream of their own and must not be disconnected.
+func (es *EventSubs) newSub(filter *eventFilter, policy OverflowPolicy) *Subscription {
 	es.mux.Lock()
 	defer es.mux.Unlock()
 
 	es.id++
-	es.subs[es.id] = make(chan *Event)
+	events := make(chan *Event, es.bufferSize)
+	sub := &Subscription{
+		ID:       es.id,
+		Events:   events,
+		StartSeq: es.seq,
+		events:   events,
+		filter:   filter,
+		policy:   policy,
+	}
+	if es.closed {
+		sub.err = es.closeErr
+		close(events)
+		return sub
+	}
+	es.subs[es.id] = sub
+
+	return sub
+}
+
+// useJournal makes every published event persistent and continues
+// numbering from the last event found in the journal.
+func (es *EventSubs) useJournal(j *Journal) {
+	es.mux.Lock()
+	defer es.mux.Unlock()
+
+	es.journal = j
+	es.seq = j.LastSeq()
+}
+
+// NewSubFrom sends matching journaled events starting at fromSeq or
+// fromTimestamp through send and then subscribes to live events, so that
+// the subscriber sees every event exactly once.
+func (es *EventSubs) NewSubFrom(fromSeq uint64, fromTimestamp int64, filter *eventFilter, send func(*Event) error) (*Subscription, error) {
+	if es.journal == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "event journal is not enabled")
+	}
+
+	replay := func(from uint64, to uint64) error {
+		err := es.journal.Replay(from, fromTimestamp, to, func(e *Event) error {
+			if !filter.Match(e) {
+				return nil
+			}
+			return send(e)
+		})
+		// like without a journal, the client can only go on with live events
+		var gap *JournalGapError
+		if errors.As(err, &gap) {
+			return status.Errorf(codes.FailedPrecondition, "%v", err)
+		}
+		return err
+	}
 
-	return es.id, es.subs[es.id]
+	// catch up from disk first, so that only a short tail has to be
+	// replayed while live events pile up in the subscriber buffer
+	next := fromSeq
+	for {
+		written := es.journal.Written()
+		if written < next || written-next < uint64(es.bufferSize/2) {
+			break
+		}
+		if err := replay(next, written); err != nil {
+			return nil, err
+		}
+		next = written + 1
+	}
+
+	sub := es.NewSub(filter)
+	err := es.journal.WaitWritten(sub.StartSeq)
+	if err != nil {
+		// the server is stopping
+		err = status.Errorf(codes.Unavailable, "%v", err)
+	} else if sub.StartSeq >= next {
+		err = replay(next, sub.StartSeq)
+	}
+	if err != nil {
+		es.RemoveSub(sub.ID)
+		return nil, err
+	}
+
+	return sub, nil
 }
 
 func (es *EventSubs) RemoveSub(id int) {
 	es.mux.Lock()
 	defer es.mux.Unlock()
 
-	if ch, ok := es.subs[id]; ok {
-		close(ch)
+	if sub, ok := es.subs[id]; ok {
+		close(sub.events)
 		delete(es.subs, id)
 	}
 }
 
-func (es *EventSubs) RemoveAll() {
+// Close ends every subscription with err and makes new ones end right away.
+func (es *EventSubs) Close(err error) {
 	es.mux.Lock()
 	defer es.mux.Unlock()
 
-	for _, ch := range es.subs {
-		close(ch)
+	for _, sub := range es.subs {
+		sub.err = err
+		close(sub.events)
 	}
-	es.subs = map[int]chan *Event{}
+	es.subs = map[int]*Subscription{}
+	es.closed = true
+	es.closeErr = err
+}
+
+func (es *EventSubs) drop(sub *Subscription) uint64 {
+	es.dropped++
+
+	return atomic.AddUint64(&sub.dropped, 1)
 }
 
+// Observe registers fn to be called with every event, results included.
+// fn runs under the lock of Notify and must be fast and must not block.
+func (es *EventSubs) Observe(fn func(*Event)) {
+	es.mux.Lock()
+	defer es.mux.Unlock()
+
+	es.observers = append(es.observers, fn)
+}
+
+// Stats returns the number of subscribers and how many events they have
+// lost in total.
+func (es *EventSubs) Stats() (int, uint64) {
+	es.mux.Lock()
+	defer es.mux.Unlock()
+
+	return len(es.subs), es.dropped
+}
+
+// Notify never blocks: a subscriber whose buffer is full loses events
+// according to its overflow policy instead of stalling the caller.
 func (es *EventSubs) Notify(e *Event) {
 	es.mux.Lock()
 	defer es.mux.Unlock()
 
-	for _, ch := range es.subs {
-		ch <- e
+	if e.Result == nil {
+		es.seq++
+		e.Seq = es.seq
+		if es.journal != nil {
+			es.journal.Append(e)
+		}
+	}
+
+	for _, observe := range es.observers {
+		observe(e)
+	}
+
+	for id, sub := range es.subs {
+		if !sub.filter.Match(e) {
+			continue
+		}
+
+		select {
+		case sub.events <- e:
+			continue
+		default:
+		}
+
+		switch sub.policy {
+		case DropOldest:
+			select {
+			case <-sub.events:
+				es.drop(sub)
+			default:
+			}
+			select {
+			case sub.events <- e:
+			default:
+				es.drop(sub)
+			}
+		case DropNewest:
+			es.drop(sub)
+		case Disconnect:
+			dropped := es.drop(sub)
+			sub.err = status.Errorf(codes.ResourceExhausted, "subscriber is too slow, %d events dropped", dropped)
+			close(sub.events)
+			delete(es.subs, id)
+		}
 	}
 }
 
 type StatisticsCollector struct {
-	stat Stat
+	stat    Stat
+	latency map[string]*latencyHistogram
 }
 
 func newStatisticsCollector() *StatisticsCollector {
@@ -150,88 +504,114 @@ func newStatisticsCollector() *StatisticsCollector {
 
 func (sc *StatisticsCollector) reset() {
 	sc.stat = Stat{
-		ByMethod:   map[string]uint64{},
-		ByConsumer: map[string]uint64{},
+		ByMethod:       map[string]uint64{},
+		ByConsumer:     map[string]uint64{},
+		ByCode:         map[string]uint64{},
+		ErrorsByMethod: map[string]uint64{},
 	}
+	sc.latency = map[string]*latencyHistogram{}
 }
 
 func (sc *StatisticsCollector) Update(e *Event) {
+	// ACL changes and alerts are not calls
+	if e.AclChange != nil || e.Alert != nil {
+		return
+	}
+
+	if e.Result != nil {
+		h, ok := sc.latency[e.Method]
+		if !ok {
+			h = newLatencyHistogram()
+			sc.latency[e.Method] = h
+		}
+		h.Observe(e.Result.LatencyMicros)
+
+		sc.stat.ByCode[e.Result.Code]++
+		if e.Result.Code != codes.OK.String() {
+			sc.stat.ErrorsByMethod[e.Method]++
+		}
+
+		return
+	}
+
 	sc.stat.ByMethod[e.Method]++
 	sc.stat.ByConsumer[e.Consumer]++
 }
 
-func (sc *StatisticsCollector) Collect() *Stat {
+func (sc *StatisticsCollector) Merge(other *StatisticsCollector) {
+	counts.Add(sc.stat.ByMethod, other.stat.ByMethod)
+	counts.Add(sc.stat.ByConsumer, other.stat.ByConsumer)
+	counts.Add(sc.stat.ByCode, other.stat.ByCode)
+	counts.Add(sc.stat.ErrorsByMethod, other.stat.ErrorsByMethod)
+
+	for method, oh := range other.latency {
+		h, ok := sc.latency[method]
+		if !ok {
+			h = newLatencyHistogram()
+			sc.latency[method] = h
+		}
+		h.Merge(oh)
+	}
+}
+
+// Snapshot returns the counters so far, the collector keeps counting.
+func (sc *StatisticsCollector) Snapshot() *Stat {
 	stat := Stat{
-		ByMethod:   sc.stat.ByMethod,
-		ByConsumer: sc.stat.ByConsumer,
+		ByMethod:        counts.Add(nil, sc.stat.ByMethod),
+		ByConsumer:      counts.Add(nil, sc.stat.ByConsumer),
+		ByCode:          counts.Add(nil, sc.stat.ByCode),
+		ErrorsByMethod:  counts.Add(nil, sc.stat.ErrorsByMethod),
+		LatencyByMethod: make(map[string]*Latency, len(sc.latency)),
+	}
+	for method, h := range sc.latency {
+		stat.LatencyByMethod[method] = h.Latency()
 	}
 	stat.Timestamp = time.Now().Unix()
-	sc.reset()
 
 	return &stat
 }
 
-type aclMethods [][]string
+// Collect returns the counters so far and starts over.
+func (sc *StatisticsCollector) Collect() *Stat {
+	stat := sc.Snapshot()
+	sc.reset()
 
-type aclAuth struct {
-	acl map[string]aclMethods
+	return stat
 }
 
-func newAclAuth(aclData string) (*aclAuth, error) {
-	acl := make(map[string][]string)
-	if err := json.Unmarshal([]byte(aclData), &acl); err != nil {
-		return nil, fmt.Errorf("failed to parse ACL data: %s", err)
-	}
+type consumerCtxKey struct{}
 
-	auth := &aclAuth{
-		acl: make(map[string]aclMethods, len(acl)),
-	}
+func consumerFromContext(ctx context.Context) string {
+	consumer, _ := ctx.Value(consumerCtxKey{}).(string)
 
-	for consumer, methods := range acl {
-		auth.acl[consumer] = make(aclMethods, len(methods))
-		for i, method := range methods {
-			auth.acl[consumer][i] = strings.Split(method, "/")
-		}
-	}
-
-	return auth, nil
+	return consumer
 }
 
-func (aa *aclAuth) isAllowed(consumer string, method string) bool {
-	methodParts := strings.Split(method, "/")
-
-	if allowedMethods, ok := aa.acl[consumer]; ok {
-	allowedLoop:
-		for _, allowedMethod := range allowedMethods {
-			if len(allowedMethod) > len(methodParts) {
-				continue
-			}
-
-			for methodIndex, methodPart := range allowedMethod {
-				if methodParts[methodIndex] == methodPart || methodPart == "*" {
-					continue
-				} else {
-					break allowedLoop
-				}
-			}
-
-			return true
-		}
-	}
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
 
-	return false
+func (ss *serverStream) Context() context.Context {
+	return ss.ctx
 }
 
 type middleware struct {
-	ServerOptions []grpc.ServerOption
-	auth          *aclAuth
-	subs          *EventSubs
+	ServerOptions  []grpc.ServerOption
+	acl            *aclStore
+	subs           *EventSubs
+	authenticators []Authenticator
+	limiter        *rateLimiter
+	exporter       SpanExporter
 }
 
-func newAuthMiddleware(auth *aclAuth, subs *EventSubs) *middleware {
+func newAuthMiddleware(acl *aclStore, subs *EventSubs, authenticators []Authenticator, exporter SpanExporter) *middleware {
 	mw := &middleware{
-		auth: auth,
-		subs: subs,
+		acl:            acl,
+		subs:           subs,
+		authenticators: authenticators,
+		limiter:        newRateLimiter(),
+		exporter:       exporter,
 	}
 	mw.ServerOptions = []grpc.ServerOption{
 		grpc.UnaryInterceptor(mw.unaryInterceptor),
@@ -241,64 +621,387 @@ func newAuthMiddleware(auth *aclAuth, subs *EventSubs) *middleware {
 	return mw
 }
 
+// isHealthCheck tells calls of the health service, they are answered to
+// anyone and are not logged: load balancers have no consumer to present
+// and would flood the log.
+func isHealthCheck(method string) bool {
+	return strings.HasPrefix(method, "/grpc.health.v1.Health/")
+}
+
 func (mw *middleware) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-	if err := mw.process(ctx, info.FullMethod); err != nil {
+	if isHealthCheck(info.FullMethod) {
+		return handler(ctx, req)
+	}
+
+	span := startSpan(ctx, info.FullMethod)
+
+	ctx, call, err := mw.process(ctx, span)
+	if err != nil {
+		mw.complete(call, span, err)
 		return nil, err
 	}
 
-	return handler(ctx, req)
+	resp, err := handler(ctx, req)
+	mw.complete(call, span, err)
+
+	return resp, err
 }
 
 func (mw *middleware) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-	if err := mw.process(ss.Context(), info.FullMethod); err != nil {
-		return err
+	if isHealthCheck(info.FullMethod) {
+		return handler(srv, ss)
 	}
 
-	return handler(srv, ss)
-}
+	span := startSpan(ss.Context(), info.FullMethod)
+
+	ctx, call, err := mw.process(ss.Context(), span)
+	if err != nil {
+		mw.complete(call, span, err)
+		return err
+	}
 
-func (mw *middleware) process(ctx context.Context, method string) error {
-	md, _ := metadata.FromIncomingContext(ctx)
+	err = handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	mw.complete(call, span, err)
 
-	consumer := strings.Join(md.Get("consumer"), "")
+	return err
+}
 
+// process authenticates and authorizes the call and publishes it as an event.
+func (mw *middleware) process(ctx context.Context, span *Span) (context.Context, *Event, error) {
+	method := span.Name
 	host := ""
 	if p, ok := peer.FromContext(ctx); ok {
 		host = p.Addr.String()
 	}
 
-	mw.subs.Notify(&Event{
+	var decision aclDecision
+	code := codes.Unauthenticated
+	throttled := false
+	tenant := ""
+
+	consumer, err := authenticate(ctx, mw.authenticators)
+	if err != nil {
+		decision.reason = "authentication failed: " + err.Error()
+	} else {
+		auth := mw.acl.Load()
+		decision = auth.authorize(consumer, method)
+		tenant = auth.tenants[consumer]
+
+		if decision.allowed {
+			if ok, reason := mw.limiter.Allow(consumer, method, auth.limits[consumer]); !ok {
+				decision = aclDecision{reason: "throttled: " + reason}
+				code = codes.ResourceExhausted
+				throttled = true
+			}
+		}
+	}
+
+	call := &Event{
 		Method:    method,
 		Consumer:  consumer,
 		Host:      host,
 		Timestamp: time.Now().Unix(),
+		Denied:    !decision.allowed,
+		Throttled: throttled,
+		Reason:    decision.reason,
+		TraceId:   span.TraceID,
+		SpanId:    span.SpanID,
+		Tenant:    tenant,
+	}
+	mw.subs.Notify(call)
+
+	if !decision.allowed {
+		if throttled {
+			return nil, call, status.Errorf(code, "%s", decision.reason)
+		}
+		return nil, call, status.Errorf(code, "access denied")
+	}
+
+	ctx = context.WithValue(ctx, consumerCtxKey{}, consumer)
+
+	return contextWithSpan(ctx, span), call, nil
+}
+
+// complete publishes how the call has ended and how long it took, and
+// exports its span.
+func (mw *middleware) complete(call *Event, span *Span, err error) {
+	span.End = time.Now()
+	span.Consumer = call.Consumer
+	span.Host = call.Host
+	span.Code = status.Code(err).String()
+
+	mw.subs.Notify(&Event{
+		Method:    call.Method,
+		Consumer:  call.Consumer,
+		Host:      call.Host,
+		Timestamp: span.End.Unix(),
+		Denied:    call.Denied,
+		TraceId:   call.TraceId,
+		SpanId:    call.SpanId,
+		Tenant:    call.Tenant,
+		Result: &CallResult{
+			Code:          span.Code,
+			LatencyMicros: span.End.Sub(span.Start).Microseconds(),
+		},
 	})
 
-	if !mw.auth.isAllowed(consumer, method) {
-		return status.Errorf(codes.Unauthenticated, "access denied")
+	if mw.exporter != nil && span.Sampled {
+		mw.exporter.Export(span)
 	}
+}
 
-	return nil
+type options struct {
+	subBufferSize   int
+	subPolicy       OverflowPolicy
+	aclFile         string
+	aclPollInterval time.Duration
+	authenticators  []Authenticator
+	serverOptions   []grpc.ServerOption
+	journal         *JournalConfig
+	metricsAddr     string
+	drainTimeout    time.Duration
+	spanExporter    SpanExporter
+	storage         CounterStorage
+	accessLogs      []accessLogConfig
+	spillDir        string
+	alertRules      []AlertRule
+	webhookURL      string
+	webhookTimeout  time.Duration
+}
+
+type accessLogConfig struct {
+	name       string
+	sink       AccessLogSink
+	bufferSize int
+}
+
+type Option func(*options)
+
+// WithSubscriberBuffer sets how many events every Logging and Statistics
+// stream may lag behind and what happens once that limit is reached.
+func WithSubscriberBuffer(size int, policy OverflowPolicy) Option {
+	return func(o *options) {
+		o.subBufferSize = size
+		o.subPolicy = policy
+	}
+}
+
+// WithACLFile makes the server re-read path every interval and apply it as
+// the new ACL whenever its contents change.
+func WithACLFile(path string, interval time.Duration) Option {
+	return func(o *options) {
+		o.aclFile = path
+		o.aclPollInterval = interval
+	}
+}
+
+// WithAuthenticators replaces the default LocalMetadataAuthenticator, a server
+// refuses to start without any. Authenticators are tried in the given order.
+func WithAuthenticators(authenticators ...Authenticator) Option {
+	return func(o *options) {
+		o.authenticators = authenticators
+	}
+}
+
+// WithServerOptions passes extra options, e.g. grpc.Creds, to grpc.NewServer.
+func WithServerOptions(serverOptions ...grpc.ServerOption) Option {
+	return func(o *options) {
+		o.serverOptions = append(o.serverOptions, serverOptions...)
+	}
+}
+
+// WithJournal persists every event so Logging can replay history.
+func WithJournal(cfg JournalConfig) Option {
+	return func(o *options) {
+		o.journal = &cfg
+	}
+}
+
+// WithMetricsListener serves Prometheus metrics on http://addr/metrics.
+func WithMetricsListener(addr string) Option {
+	return func(o *options) {
+		o.metricsAddr = addr
+	}
+}
+
+// WithDrainTimeout limits how long running calls may finish after ctx is
+// cancelled, the rest are cut off.
+func WithDrainTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.drainTimeout = timeout
+	}
+}
+
+// WithSpanExporter sends a span of every sampled call to exporter.
+func WithSpanExporter(exporter SpanExporter) Option {
+	return func(o *options) {
+		o.spanExporter = exporter
+	}
+}
+
+// WithStorage keeps Biz counters in storage instead of memory. The server
+// closes it once stopped.
+func WithStorage(storage CounterStorage) Option {
+	return func(o *options) {
+		o.storage = storage
+	}
+}
+
+// WithAccessLog writes every event, call results and ACL changes included,
+// to sink. Up to bufferSize events wait for a slow sink in memory, the
+// following ones in a spill file, see WithSpillDir. Events are only dropped
+// when the spill file can not be written, they are counted in the metrics
+// under name. The server closes the sink once everything is written.
+func WithAccessLog(name string, sink AccessLogSink, bufferSize int) Option {
+	return func(o *options) {
+		o.accessLogs = append(o.accessLogs, accessLogConfig{name: name, sink: sink, bufferSize: bufferSize})
+	}
 }
 
-func StartMyMicroservice(ctx context.Context, listenAddr string, aclData string) error {
+// WithSpillDir sets where access logs keep the events their sinks have not
+// caught up with, the default is the temporary directory. The files are
+// removed once written, those left by a crash are not read back.
+func WithSpillDir(dir string) Option {
+	return func(o *options) {
+		o.spillDir = dir
+	}
+}
+
+// WithAlertRules evaluates rules over every call. Alerts are published as
+// events, so they show up in Logging and the access logs as well as on the
+// Admin Alerts stream.
+func WithAlertRules(rules ...AlertRule) Option {
+	return func(o *options) {
+		o.alertRules = append(o.alertRules, rules...)
+	}
+}
+
+// WithAlertWebhook posts every alert as JSON to url, giving up on a post
+// after timeout, 5 seconds when it is zero.
+func WithAlertWebhook(url string, timeout time.Duration) Option {
+	return func(o *options) {
+		o.webhookURL = url
+		o.webhookTimeout = timeout
+	}
+}
+
+func StartMyMicroservice(ctx context.Context, listenAddr string, aclData string, opts ...Option) error {
+	o := &options{
+		subBufferSize:  256,
+		subPolicy:      DropOldest,
+		authenticators: []Authenticator{LocalMetadataAuthenticator{}},
+		drainTimeout:   10 * time.Second,
+		storage:        NewMemoryStorage(),
+		spillDir:       os.TempDir(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	acl, err := newAclAuth(aclData)
 	if err != nil {
 		return err
 	}
+	for _, rule := range o.alertRules {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+	}
+	if len(o.authenticators) == 0 {
+		return fmt.Errorf("no authenticators, see WithAuthenticators")
+	}
+
+	var journal *Journal
+	if o.journal != nil {
+		journal, err = OpenJournal(*o.journal)
+		if err != nil {
+			return err
+		}
+	}
 
 	l, err := net.Listen("tcp", listenAddr)
 	if err != nil {
+		if journal != nil {
+			journal.Close()
+		}
 		return err
 	}
 
-	subs := newEventSubs()
-	mw := newAuthMiddleware(acl, subs)
+	var metricsListener net.Listener
+	if o.metricsAddr != "" {
+		metricsListener, err = net.Listen("tcp", o.metricsAddr)
+		if err != nil {
+			l.Close()
+			if journal != nil {
+				journal.Close()
+			}
+			return err
+		}
+	}
+
+	subs := newEventSubs(o.subBufferSize, o.subPolicy)
+	if journal != nil {
+		subs.useJournal(journal)
+	}
+	aclStore := newAclStore(acl, subs)
+	mw := newAuthMiddleware(aclStore, subs, o.authenticators, o.spanExporter)
+	totals := newServerMetrics(subs)
+
+	accessLogs := make([]*bufferedSink, len(o.accessLogs))
+	for i, cfg := range o.accessLogs {
+		accessLogs[i] = newSpillingSink(cfg.name, cfg.sink, cfg.bufferSize, o.spillDir)
+		subs.Observe(accessLogs[i].Offer)
+	}
+	totals.accessLogs = accessLogs
+	totals.journal = journal
+
+	// the webhook goes after the alerter, so that it gets the last alerts
+	// before being closed
+	var alerting []*bufferedSink
+	if len(o.alertRules) > 0 {
+		feed := newBufferedSink("alerts", newAlerter(o.alertRules, subs), o.subBufferSize)
+		subs.Observe(feed.Offer)
+		alerting = append(alerting, feed)
+	}
+	if o.webhookURL != "" {
+		webhook := newBufferedSink("webhook", newWebhookSink(o.webhookURL, o.webhookTimeout), o.subBufferSize)
+		subs.Observe(func(e *Event) {
+			if e.Alert != nil {
+				webhook.Offer(e)
+			}
+		})
+		alerting = append(alerting, webhook)
+	}
 
-	server := grpc.NewServer(mw.ServerOptions...)
+	server := grpc.NewServer(append(mw.ServerOptions, o.serverOptions...)...)
 
-	RegisterBizServer(server, NewBizServer())
-	RegisterAdminServer(server, NewAdminServer(subs))
+	RegisterBizServer(server, NewBizServer(o.storage))
+	RegisterAdminServer(server, NewAdminServer(subs, aclStore, totals))
+
+	healthServer := health.NewServer()
+	for _, service := range []string{"", Biz_ServiceDesc.ServiceName, Admin_ServiceDesc.ServiceName} {
+		healthServer.SetServingStatus(service, healthpb.HealthCheckResponse_SERVING)
+	}
+	healthpb.RegisterHealthServer(server, healthServer)
+	// goes through the ACL like any other service
+	reflection.Register(server)
+
+	if o.aclFile != "" {
+		go aclStore.watch(ctx, o.aclFile, o.aclPollInterval, aclData)
+	}
+
+	var metricsServer *http.Server
+	if metricsListener != nil {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", totals)
+		metricsServer = &http.Server{Handler: mux}
+
+		go func() {
+			if err := metricsServer.Serve(metricsListener); err != nil && err != http.ErrServerClosed {
+				fmt.Printf("cant start metrics server: %v", err)
+			}
+		}()
+	}
 
 	go func() {
 		err := server.Serve(l)
@@ -310,9 +1013,40 @@ func StartMyMicroservice(ctx context.Context, listenAddr string, aclData string)
 	go func() {
 		<-ctx.Done()
 
-		subs.RemoveAll()
+		healthServer.Shutdown()
+		subs.Close(status.Errorf(codes.Unavailable, "server is shutting down"))
+
+		if metricsServer != nil {
+			metricsServer.Close()
+		}
+
+		stopped := make(chan struct{})
+		go func() {
+			server.GracefulStop()
+			close(stopped)
+		}()
+
+		drain := time.NewTimer(o.drainTimeout)
+		select {
+		case <-stopped:
+			drain.Stop()
+		case <-drain.C:
+			// health watchers and clients stuck in Send never finish by
+			// themselves
+			server.Stop()
+			<-stopped
+		}
 
-		server.GracefulStop()
+		for _, sink := range alerting {
+			sink.Close()
+		}
+		if journal != nil {
+			journal.Close()
+		}
+		o.storage.Close()
+		for _, sink := range accessLogs {
+			sink.Close()
+		}
 	}()
 
 	return nil
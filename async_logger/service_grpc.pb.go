@@ -22,8 +22,11 @@ const _ = grpc.SupportPackageIsVersion7
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type AdminClient interface {
-	Logging(ctx context.Context, in *Nothing, opts ...grpc.CallOption) (Admin_LoggingClient, error)
+	Logging(ctx context.Context, in *LogFilter, opts ...grpc.CallOption) (Admin_LoggingClient, error)
 	Statistics(ctx context.Context, in *StatInterval, opts ...grpc.CallOption) (Admin_StatisticsClient, error)
+	UpdateACL(ctx context.Context, in *AclDocument, opts ...grpc.CallOption) (*AclChange, error)
+	Control(ctx context.Context, opts ...grpc.CallOption) (Admin_ControlClient, error)
+	Alerts(ctx context.Context, in *Nothing, opts ...grpc.CallOption) (Admin_AlertsClient, error)
 }
 
 type adminClient struct {
@@ -34,7 +37,7 @@ func NewAdminClient(cc grpc.ClientConnInterface) AdminClient {
 	return &adminClient{cc}
 }
 
-func (c *adminClient) Logging(ctx context.Context, in *Nothing, opts ...grpc.CallOption) (Admin_LoggingClient, error) {
+func (c *adminClient) Logging(ctx context.Context, in *LogFilter, opts ...grpc.CallOption) (Admin_LoggingClient, error) {
 	stream, err := c.cc.NewStream(ctx, &Admin_ServiceDesc.Streams[0], "/main.Admin/Logging", opts...)
 	if err != nil {
 		return nil, err
@@ -98,12 +101,87 @@ func (x *adminStatisticsClient) Recv() (*Stat, error) {
 	return m, nil
 }
 
+func (c *adminClient) UpdateACL(ctx context.Context, in *AclDocument, opts ...grpc.CallOption) (*AclChange, error) {
+	out := new(AclChange)
+	err := c.cc.Invoke(ctx, "/main.Admin/UpdateACL", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Control(ctx context.Context, opts ...grpc.CallOption) (Admin_ControlClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Admin_ServiceDesc.Streams[2], "/main.Admin/Control", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &adminControlClient{stream}
+	return x, nil
+}
+
+type Admin_ControlClient interface {
+	Send(*ControlRequest) error
+	Recv() (*ControlMessage, error)
+	grpc.ClientStream
+}
+
+type adminControlClient struct {
+	grpc.ClientStream
+}
+
+func (x *adminControlClient) Send(m *ControlRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *adminControlClient) Recv() (*ControlMessage, error) {
+	m := new(ControlMessage)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *adminClient) Alerts(ctx context.Context, in *Nothing, opts ...grpc.CallOption) (Admin_AlertsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Admin_ServiceDesc.Streams[3], "/main.Admin/Alerts", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &adminAlertsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Admin_AlertsClient interface {
+	Recv() (*Alert, error)
+	grpc.ClientStream
+}
+
+type adminAlertsClient struct {
+	grpc.ClientStream
+}
+
+func (x *adminAlertsClient) Recv() (*Alert, error) {
+	m := new(Alert)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // AdminServer is the server API for Admin service.
 // All implementations must embed UnimplementedAdminServer
 // for forward compatibility
 type AdminServer interface {
-	Logging(*Nothing, Admin_LoggingServer) error
+	Logging(*LogFilter, Admin_LoggingServer) error
 	Statistics(*StatInterval, Admin_StatisticsServer) error
+	UpdateACL(context.Context, *AclDocument) (*AclChange, error)
+	Control(Admin_ControlServer) error
+	Alerts(*Nothing, Admin_AlertsServer) error
 	mustEmbedUnimplementedAdminServer()
 }
 
@@ -111,12 +189,21 @@ type AdminServer interface {
 type UnimplementedAdminServer struct {
 }
 
-func (UnimplementedAdminServer) Logging(*Nothing, Admin_LoggingServer) error {
+func (UnimplementedAdminServer) Logging(*LogFilter, Admin_LoggingServer) error {
 	return status.Errorf(codes.Unimplemented, "method Logging not implemented")
 }
 func (UnimplementedAdminServer) Statistics(*StatInterval, Admin_StatisticsServer) error {
 	return status.Errorf(codes.Unimplemented, "method Statistics not implemented")
 }
+func (UnimplementedAdminServer) UpdateACL(context.Context, *AclDocument) (*AclChange, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateACL not implemented")
+}
+func (UnimplementedAdminServer) Control(Admin_ControlServer) error {
+	return status.Errorf(codes.Unimplemented, "method Control not implemented")
+}
+func (UnimplementedAdminServer) Alerts(*Nothing, Admin_AlertsServer) error {
+	return status.Errorf(codes.Unimplemented, "method Alerts not implemented")
+}
 func (UnimplementedAdminServer) mustEmbedUnimplementedAdminServer() {}
 
 // UnsafeAdminServer may be embedded to opt out of forward compatibility for this service.
@@ -131,7 +218,7 @@ func RegisterAdminServer(s grpc.ServiceRegistrar, srv AdminServer) {
 }
 
 func _Admin_Logging_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(Nothing)
+	m := new(LogFilter)
 	if err := stream.RecvMsg(m); err != nil {
 		return err
 	}
@@ -172,13 +259,83 @@ func (x *adminStatisticsServer) Send(m *Stat) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Admin_UpdateACL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AclDocument)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).UpdateACL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/main.Admin/UpdateACL",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).UpdateACL(ctx, req.(*AclDocument))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_Control_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AdminServer).Control(&adminControlServer{stream})
+}
+
+type Admin_ControlServer interface {
+	Send(*ControlMessage) error
+	Recv() (*ControlRequest, error)
+	grpc.ServerStream
+}
+
+type adminControlServer struct {
+	grpc.ServerStream
+}
+
+func (x *adminControlServer) Send(m *ControlMessage) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *adminControlServer) Recv() (*ControlRequest, error) {
+	m := new(ControlRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Admin_Alerts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(Nothing)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AdminServer).Alerts(m, &adminAlertsServer{stream})
+}
+
+type Admin_AlertsServer interface {
+	Send(*Alert) error
+	grpc.ServerStream
+}
+
+type adminAlertsServer struct {
+	grpc.ServerStream
+}
+
+func (x *adminAlertsServer) Send(m *Alert) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // Admin_ServiceDesc is the grpc.ServiceDesc for Admin service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
 var Admin_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "main.Admin",
 	HandlerType: (*AdminServer)(nil),
-	Methods:     []grpc.MethodDesc{},
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "UpdateACL",
+			Handler:    _Admin_UpdateACL_Handler,
+		},
+	},
 	Streams: []grpc.StreamDesc{
 		{
 			StreamName:    "Logging",
@@ -190,6 +347,17 @@ var Admin_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Admin_Statistics_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "Control",
+			Handler:       _Admin_Control_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Alerts",
+			Handler:       _Admin_Alerts_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "service.proto",
 }
@@ -198,9 +366,9 @@ var Admin_ServiceDesc = grpc.ServiceDesc{
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type BizClient interface {
-	Check(ctx context.Context, in *Nothing, opts ...grpc.CallOption) (*Nothing, error)
-	Add(ctx context.Context, in *Nothing, opts ...grpc.CallOption) (*Nothing, error)
-	Test(ctx context.Context, in *Nothing, opts ...grpc.CallOption) (*Nothing, error)
+	Check(ctx context.Context, in *CounterKey, opts ...grpc.CallOption) (*Counter, error)
+	Add(ctx context.Context, in *CounterDelta, opts ...grpc.CallOption) (*Counter, error)
+	Test(ctx context.Context, in *CounterBatch, opts ...grpc.CallOption) (*BatchResult, error)
 }
 
 type bizClient struct {
@@ -211,8 +379,8 @@ func NewBizClient(cc grpc.ClientConnInterface) BizClient {
 	return &bizClient{cc}
 }
 
-func (c *bizClient) Check(ctx context.Context, in *Nothing, opts ...grpc.CallOption) (*Nothing, error) {
-	out := new(Nothing)
+func (c *bizClient) Check(ctx context.Context, in *CounterKey, opts ...grpc.CallOption) (*Counter, error) {
+	out := new(Counter)
 	err := c.cc.Invoke(ctx, "/main.Biz/Check", in, out, opts...)
 	if err != nil {
 		return nil, err
@@ -220,8 +388,8 @@ func (c *bizClient) Check(ctx context.Context, in *Nothing, opts ...grpc.CallOpt
 	return out, nil
 }
 
-func (c *bizClient) Add(ctx context.Context, in *Nothing, opts ...grpc.CallOption) (*Nothing, error) {
-	out := new(Nothing)
+func (c *bizClient) Add(ctx context.Context, in *CounterDelta, opts ...grpc.CallOption) (*Counter, error) {
+	out := new(Counter)
 	err := c.cc.Invoke(ctx, "/main.Biz/Add", in, out, opts...)
 	if err != nil {
 		return nil, err
@@ -229,8 +397,8 @@ func (c *bizClient) Add(ctx context.Context, in *Nothing, opts ...grpc.CallOptio
 	return out, nil
 }
 
-func (c *bizClient) Test(ctx context.Context, in *Nothing, opts ...grpc.CallOption) (*Nothing, error) {
-	out := new(Nothing)
+func (c *bizClient) Test(ctx context.Context, in *CounterBatch, opts ...grpc.CallOption) (*BatchResult, error) {
+	out := new(BatchResult)
 	err := c.cc.Invoke(ctx, "/main.Biz/Test", in, out, opts...)
 	if err != nil {
 		return nil, err
@@ -242,9 +410,9 @@ func (c *bizClient) Test(ctx context.Context, in *Nothing, opts ...grpc.CallOpti
 // All implementations must embed UnimplementedBizServer
 // for forward compatibility
 type BizServer interface {
-	Check(context.Context, *Nothing) (*Nothing, error)
-	Add(context.Context, *Nothing) (*Nothing, error)
-	Test(context.Context, *Nothing) (*Nothing, error)
+	Check(context.Context, *CounterKey) (*Counter, error)
+	Add(context.Context, *CounterDelta) (*Counter, error)
+	Test(context.Context, *CounterBatch) (*BatchResult, error)
 	mustEmbedUnimplementedBizServer()
 }
 
@@ -252,13 +420,13 @@ type BizServer interface {
 type UnimplementedBizServer struct {
 }
 
-func (UnimplementedBizServer) Check(context.Context, *Nothing) (*Nothing, error) {
+func (UnimplementedBizServer) Check(context.Context, *CounterKey) (*Counter, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Check not implemented")
 }
-func (UnimplementedBizServer) Add(context.Context, *Nothing) (*Nothing, error) {
+func (UnimplementedBizServer) Add(context.Context, *CounterDelta) (*Counter, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Add not implemented")
 }
-func (UnimplementedBizServer) Test(context.Context, *Nothing) (*Nothing, error) {
+func (UnimplementedBizServer) Test(context.Context, *CounterBatch) (*BatchResult, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Test not implemented")
 }
 func (UnimplementedBizServer) mustEmbedUnimplementedBizServer() {}
@@ -275,7 +443,7 @@ func RegisterBizServer(s grpc.ServiceRegistrar, srv BizServer) {
 }
 
 func _Biz_Check_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Nothing)
+	in := new(CounterKey)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -287,13 +455,13 @@ func _Biz_Check_Handler(srv interface{}, ctx context.Context, dec func(interface
 		FullMethod: "/main.Biz/Check",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(BizServer).Check(ctx, req.(*Nothing))
+		return srv.(BizServer).Check(ctx, req.(*CounterKey))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
 func _Biz_Add_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Nothing)
+	in := new(CounterDelta)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -305,13 +473,13 @@ func _Biz_Add_Handler(srv interface{}, ctx context.Context, dec func(interface{}
 		FullMethod: "/main.Biz/Add",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(BizServer).Add(ctx, req.(*Nothing))
+		return srv.(BizServer).Add(ctx, req.(*CounterDelta))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
 func _Biz_Test_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Nothing)
+	in := new(CounterBatch)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -323,7 +491,7 @@ func _Biz_Test_Handler(srv interface{}, ctx context.Context, dec func(interface{
 		FullMethod: "/main.Biz/Test",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(BizServer).Test(ctx, req.(*Nothing))
+		return srv.(BizServer).Test(ctx, req.(*CounterBatch))
 	}
 	return interceptor(ctx, in, info, handler)
 }
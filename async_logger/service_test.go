@@ -146,7 +146,7 @@ func TestACL(t *testing.T) {
 		getConsumerCtx("unknown"),  // поле есть, неизвестный консюмер
 		getConsumerCtx("biz_user"), // поле есть, нет доступа
 	} {
-		_, err = biz.Test(ctx, &Nothing{})
+		_, err = biz.Test(ctx, &CounterBatch{})
 		if err == nil {
 			t.Fatalf("[%d] ACL fail: expected err on disallowed method", idx)
 		} else if code := grpc.Code(err); code != codes.Unauthenticated {
@@ -155,21 +155,21 @@ func TestACL(t *testing.T) {
 	}
 
 	// есть доступ
-	_, err = biz.Check(getConsumerCtx("biz_user"), &Nothing{})
+	_, err = biz.Check(getConsumerCtx("biz_user"), &CounterKey{})
 	if err != nil {
 		t.Fatalf("ACL fail: unexpected error: %v", err)
 	}
-	_, err = biz.Check(getConsumerCtx("biz_admin"), &Nothing{})
+	_, err = biz.Check(getConsumerCtx("biz_admin"), &CounterKey{})
 	if err != nil {
 		t.Fatalf("ACL fail: unexpected error: %v", err)
 	}
-	_, err = biz.Test(getConsumerCtx("biz_admin"), &Nothing{})
+	_, err = biz.Test(getConsumerCtx("biz_admin"), &CounterBatch{})
 	if err != nil {
 		t.Fatalf("ACL fail: unexpected error: %v", err)
 	}
 
 	// ACL на методах, которые возвращают поток данных
-	logger, err := adm.Logging(getConsumerCtx("unknown"), &Nothing{})
+	logger, err := adm.Logging(getConsumerCtx("unknown"), &LogFilter{})
 	_, err = logger.Recv()
 	if err == nil {
 		t.Fatalf("ACL fail: expected err on disallowed method")
@@ -196,10 +196,10 @@ func TestLogging(t *testing.T) {
 	biz := NewBizClient(conn)
 	adm := NewAdminClient(conn)
 
-	logStream1, err := adm.Logging(getConsumerCtx("logger1"), &Nothing{})
+	logStream1, err := adm.Logging(getConsumerCtx("logger1"), &LogFilter{})
 	time.Sleep(1 * time.Millisecond)
 
-	logStream2, err := adm.Logging(getConsumerCtx("logger2"), &Nothing{})
+	logStream2, err := adm.Logging(getConsumerCtx("logger2"), &LogFilter{})
 
 	logData1 := []*Event{}
 	logData2 := []*Event{}
@@ -258,13 +258,13 @@ func TestLogging(t *testing.T) {
 		}
 	}()
 
-	biz.Check(getConsumerCtx("biz_user"), &Nothing{})
+	biz.Check(getConsumerCtx("biz_user"), &CounterKey{})
 	time.Sleep(2 * time.Millisecond)
 
-	biz.Check(getConsumerCtx("biz_admin"), &Nothing{})
+	biz.Check(getConsumerCtx("biz_admin"), &CounterKey{})
 	time.Sleep(2 * time.Millisecond)
 
-	biz.Test(getConsumerCtx("biz_admin"), &Nothing{})
+	biz.Test(getConsumerCtx("biz_admin"), &CounterBatch{})
 	time.Sleep(2 * time.Millisecond)
 
 	wg.Wait()
@@ -362,9 +362,9 @@ func TestStat(t *testing.T) {
 
 	wait(1)
 
-	biz.Check(getConsumerCtx("biz_user"), &Nothing{})
-	biz.Add(getConsumerCtx("biz_user"), &Nothing{})
-	biz.Test(getConsumerCtx("biz_admin"), &Nothing{})
+	biz.Check(getConsumerCtx("biz_user"), &CounterKey{})
+	biz.Add(getConsumerCtx("biz_user"), &CounterDelta{})
+	biz.Test(getConsumerCtx("biz_admin"), &CounterBatch{})
 
 	wait(200) // 2 sec
 
@@ -388,7 +388,7 @@ func TestStat(t *testing.T) {
 	}
 	mu.Unlock()
 
-	biz.Add(getConsumerCtx("biz_admin"), &Nothing{})
+	biz.Add(getConsumerCtx("biz_admin"), &CounterDelta{})
 
 	wait(220) // 2+ sec
 
@@ -447,10 +447,10 @@ func TestWorkAfterDisconnect(t *testing.T) {
 	adm := NewAdminClient(conn)
 
 	ctx1, cancel1 := getConsumerCtxWithCancel("logger1")
-	logStream1, err := adm.Logging(ctx1, &Nothing{})
+	logStream1, err := adm.Logging(ctx1, &LogFilter{})
 	time.Sleep(1 * time.Millisecond)
 
-	logStream2, err := adm.Logging(getConsumerCtx("logger2"), &Nothing{})
+	logStream2, err := adm.Logging(getConsumerCtx("logger2"), &LogFilter{})
 
 	logData1 := []*Event{}
 	logData2 := []*Event{}
@@ -510,13 +510,13 @@ func TestWorkAfterDisconnect(t *testing.T) {
 		}
 	}()
 
-	biz.Check(getConsumerCtx("biz_user"), &Nothing{})
+	biz.Check(getConsumerCtx("biz_user"), &CounterKey{})
 	time.Sleep(2 * time.Millisecond)
 
-	biz.Check(getConsumerCtx("biz_admin"), &Nothing{})
+	biz.Check(getConsumerCtx("biz_admin"), &CounterKey{})
 	time.Sleep(2 * time.Millisecond)
 
-	biz.Test(getConsumerCtx("biz_admin"), &Nothing{})
+	biz.Test(getConsumerCtx("biz_admin"), &CounterBatch{})
 	time.Sleep(2 * time.Millisecond)
 
 	// CHANGED
@@ -524,9 +524,9 @@ func TestWorkAfterDisconnect(t *testing.T) {
 	cancel1()
 	wait(12)
 
-	biz.Add(getConsumerCtx("after_disconnect"), &Nothing{})
+	biz.Add(getConsumerCtx("after_disconnect"), &CounterDelta{})
 	time.Sleep(2 * time.Millisecond)
-	biz.Add(getConsumerCtx("after_disconnect"), &Nothing{})
+	biz.Add(getConsumerCtx("after_disconnect"), &CounterDelta{})
 	time.Sleep(2 * time.Millisecond)
 	// END CHANGED
 
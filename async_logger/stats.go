@@ -0,0 +1,52 @@
+package main
+
+import "time"
+
+// statWindow counts events of the last size, split into one collector per
+// second so the oldest second can be forgotten as a whole.
+type statWindow struct {
+	size    time.Duration
+	now     func() time.Time
+	buckets []statBucket // oldest first
+}
+
+type statBucket struct {
+	second int64
+	stats  *StatisticsCollector
+}
+
+func newStatWindow(size time.Duration) *statWindow {
+	return &statWindow{size: size, now: time.Now}
+}
+
+func (w *statWindow) expire(now time.Time) {
+	oldest := now.Add(-w.size).Unix()
+
+	i := 0
+	for i < len(w.buckets) && w.buckets[i].second <= oldest {
+		i++
+	}
+	w.buckets = w.buckets[i:]
+}
+
+func (w *statWindow) Update(e *Event) {
+	now := w.now()
+	w.expire(now)
+
+	second := now.Unix()
+	if n := len(w.buckets); n == 0 || w.buckets[n-1].second != second {
+		w.buckets = append(w.buckets, statBucket{second: second, stats: newStatisticsCollector()})
+	}
+	w.buckets[len(w.buckets)-1].stats.Update(e)
+}
+
+func (w *statWindow) Collect() *Stat {
+	w.expire(w.now())
+
+	total := newStatisticsCollector()
+	for _, b := range w.buckets {
+		total.Merge(b.stats)
+	}
+
+	return total.Snapshot()
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func TestStatWindow(t *testing.T) {
+	now := time.Unix(1000, 0)
+	w := newStatWindow(3 * time.Second)
+	w.now = func() time.Time { return now }
+
+	call := func(method string) {
+		w.Update(&Event{Method: method, Consumer: "biz_user"})
+		w.Update(&Event{Method: method, Result: &CallResult{Code: "OK", LatencyMicros: 100}})
+	}
+
+	call("/main.Biz/Check")
+	now = now.Add(time.Second)
+	call("/main.Biz/Add")
+	call("/main.Biz/Add")
+	now = now.Add(2 * time.Second)
+	call("/main.Biz/Test")
+
+	// the Check is exactly 3 seconds old now and falls out
+	stat := w.Collect()
+	expected := map[string]uint64{"/main.Biz/Add": 2, "/main.Biz/Test": 1}
+	if !reflect.DeepEqual(stat.ByMethod, expected) {
+		t.Fatalf("by method dont match\nhave %+v\nwant %+v", stat.ByMethod, expected)
+	}
+	if stat.ByConsumer["biz_user"] != 3 || stat.ByCode["OK"] != 3 {
+		t.Fatalf("unexpected totals %v %v", stat.ByConsumer, stat.ByCode)
+	}
+	if l := stat.LatencyByMethod["/main.Biz/Add"]; l == nil || l.Count != 2 {
+		t.Fatalf("expected 2 latency samples for Add, got %v", l)
+	}
+
+	// collecting does not reset the window
+	now = now.Add(time.Second)
+	stat = w.Collect()
+	expected = map[string]uint64{"/main.Biz/Test": 1}
+	if !reflect.DeepEqual(stat.ByMethod, expected) {
+		t.Fatalf("by method dont match\nhave %+v\nwant %+v", stat.ByMethod, expected)
+	}
+
+	now = now.Add(time.Minute)
+	if stat = w.Collect(); len(stat.ByMethod) != 0 || len(w.buckets) != 0 {
+		t.Fatalf("expected empty window, got %v", stat.ByMethod)
+	}
+}
+
+func TestStatModes(t *testing.T) {
+	ctx, finish := context.WithCancel(context.Background())
+	err := StartMyMicroservice(ctx, listenAddr, ACLData)
+	if err != nil {
+		t.Fatalf("cant start server initial: %v", err)
+	}
+	wait(1)
+	defer func() {
+		finish()
+		wait(1)
+	}()
+
+	conn := getGrpcConn(t)
+	defer conn.Close()
+
+	biz := NewBizClient(conn)
+	adm := NewAdminClient(conn)
+
+	// seen by the server-wide totals only
+	biz.Check(getConsumerCtx("biz_user"), &CounterKey{})
+
+	cumulative, err := adm.Statistics(getConsumerCtx("stat1"), &StatInterval{IntervalSeconds: 1, Mode: StatInterval_CUMULATIVE})
+	if err != nil {
+		t.Fatalf("cant open stat stream: %v", err)
+	}
+	wait(1)
+	server, err := adm.Statistics(getConsumerCtx("stat2"), &StatInterval{IntervalSeconds: 1, Mode: StatInterval_SERVER})
+	if err != nil {
+		t.Fatalf("cant open stat stream: %v", err)
+	}
+	wait(1)
+
+	biz.Add(getConsumerCtx("biz_user"), &CounterDelta{})
+
+	recv := func(stream Admin_StatisticsClient) *Stat {
+		stat, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return stat
+	}
+
+	expectedCumulative := map[string]uint64{"/main.Admin/Statistics": 1, "/main.Biz/Add": 1}
+	if stat := recv(cumulative); !reflect.DeepEqual(stat.ByMethod, expectedCumulative) {
+		t.Fatalf("cumulative dont match\nhave %+v\nwant %+v", stat.ByMethod, expectedCumulative)
+	}
+	expectedServer := map[string]uint64{"/main.Admin/Statistics": 2, "/main.Biz/Check": 1, "/main.Biz/Add": 1}
+	if stat := recv(server); !reflect.DeepEqual(stat.ByMethod, expectedServer) {
+		t.Fatalf("server dont match\nhave %+v\nwant %+v", stat.ByMethod, expectedServer)
+	}
+
+	biz.Test(getConsumerCtx("biz_admin"), &CounterBatch{})
+
+	// nothing is reset between ticks
+	expectedCumulative["/main.Biz/Test"] = 1
+	if stat := recv(cumulative); !reflect.DeepEqual(stat.ByMethod, expectedCumulative) {
+		t.Fatalf("cumulative dont match\nhave %+v\nwant %+v", stat.ByMethod, expectedCumulative)
+	}
+	expectedServer["/main.Biz/Test"] = 1
+	if stat := recv(server); !reflect.DeepEqual(stat.ByMethod, expectedServer) {
+		t.Fatalf("server dont match\nhave %+v\nwant %+v", stat.ByMethod, expectedServer)
+	}
+
+	for idx, si := range []*StatInterval{
+		{},
+		{IntervalSeconds: 1, Mode: StatInterval_WINDOW},
+		{IntervalSeconds: 1, Mode: 42},
+	} {
+		stream, err := adm.Statistics(getConsumerCtx("stat1"), si)
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		if code := grpc.Code(err); code != codes.InvalidArgument {
+			t.Fatalf("[%d] expected InvalidArgument, got %v", idx, err)
+		}
+	}
+}
+
+func TestStatResults(t *testing.T) {
+	ctx, finish := context.WithCancel(context.Background())
+	err := StartMyMicroservice(ctx, listenAddr, ACLData)
+	if err != nil {
+		t.Fatalf("cant start server initial: %v", err)
+	}
+	wait(1)
+	defer func() {
+		finish()
+		wait(1)
+	}()
+
+	conn := getGrpcConn(t)
+	defer conn.Close()
+
+	biz := NewBizClient(conn)
+	adm := NewAdminClient(conn)
+
+	statStream, err := adm.Statistics(getConsumerCtx("stat1"), &StatInterval{IntervalSeconds: 1})
+	if err != nil {
+		t.Fatalf("cant open stat stream: %v", err)
+	}
+	wait(1)
+
+	biz.Check(getConsumerCtx("biz_user"), &CounterKey{})
+	biz.Check(getConsumerCtx("biz_admin"), &CounterKey{})
+	biz.Test(getConsumerCtx("biz_user"), &CounterBatch{})
+	biz.Test(getConsumerCtx("unknown"), &CounterBatch{})
+
+	stat, err := statStream.Recv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedByCode := map[string]uint64{
+		"OK":              2,
+		"Unauthenticated": 2,
+	}
+	if !reflect.DeepEqual(stat.ByCode, expectedByCode) {
+		t.Fatalf("by code dont match\nhave %+v\nwant %+v", stat.ByCode, expectedByCode)
+	}
+	expectedErrors := map[string]uint64{
+		"/main.Biz/Test": 2,
+	}
+	if !reflect.DeepEqual(stat.ErrorsByMethod, expectedErrors) {
+		t.Fatalf("errors dont match\nhave %+v\nwant %+v", stat.ErrorsByMethod, expectedErrors)
+	}
+
+	for method, count := range map[string]uint64{"/main.Biz/Check": 2, "/main.Biz/Test": 2} {
+		latency := stat.LatencyByMethod[method]
+		if latency == nil || latency.Count != count {
+			t.Fatalf("expected %d latency samples for %s, got %v", count, method, latency)
+		}
+		if latency.P50Ms <= 0 || latency.P50Ms > latency.P90Ms || latency.P90Ms > latency.P99Ms {
+			t.Fatalf("bad percentiles for %s: %v", method, latency)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// storageError is a string error, so that sentinel errors can be constants.
+type storageError string
+
+func (e storageError) Error() string { return string(e) }
+
+// errCounterOverflow is returned when an Add would not fit into int64.
+const errCounterOverflow = storageError("counter overflow")
+
+// CounterStorage keeps the counters of the Biz service. Counters that were
+// never added to read as zero.
+type CounterStorage interface {
+	// Add changes counter name by delta and returns the new value.
+	Add(name string, delta int64) (int64, error)
+	// Get reads several counters at once, consistently with each other.
+	Get(names ...string) ([]int64, error)
+	Close() error
+}
+
+func addCounter(value int64, delta int64) (int64, error) {
+	if delta > 0 && value > math.MaxInt64-delta || delta < 0 && value < math.MinInt64-delta {
+		return value, errCounterOverflow
+	}
+
+	return value + delta, nil
+}
+
+type memoryStorage struct {
+	mux      *sync.Mutex
+	counters map[string]int64
+}
+
+// NewMemoryStorage keeps counters until the process exits.
+func NewMemoryStorage() CounterStorage {
+	return newMemoryStorage()
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{
+		mux:      &sync.Mutex{},
+		counters: map[string]int64{},
+	}
+}
+
+func (ms *memoryStorage) Add(name string, delta int64) (int64, error) {
+	ms.mux.Lock()
+	defer ms.mux.Unlock()
+
+	value, err := addCounter(ms.counters[name], delta)
+	if err != nil {
+		return value, err
+	}
+	ms.counters[name] = value
+
+	return value, nil
+}
+
+func (ms *memoryStorage) Get(names ...string) ([]int64, error) {
+	ms.mux.Lock()
+	defer ms.mux.Unlock()
+
+	values := make([]int64, len(names))
+	for i, name := range names {
+		values[i] = ms.counters[name]
+	}
+
+	return values, nil
+}
+
+func (ms *memoryStorage) Close() error {
+	return nil
+}
+
+// fileStorage appends every new value to a log of JSON lines and reads it
+// back on open, the last line of a counter wins. The log is compacted on
+// open so it only grows with the writes of a single run.
+type fileStorage struct {
+	*memoryStorage
+	path string
+	f    *os.File
+}
+
+type counterRecord struct {
+	Name  string `json:"name"`
+	Value int64  `json:"value"`
+}
+
+// OpenFileStorage loads counters from path, creating the file if needed.
+func OpenFileStorage(path string) (CounterStorage, error) {
+	fs := &fileStorage{memoryStorage: newMemoryStorage(), path: path}
+
+	if err := fs.load(); err != nil {
+		return nil, err
+	}
+	if err := fs.compact(); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	fs.f = f
+
+	return fs, nil
+}
+
+func (fs *fileStorage) load() error {
+	f, err := os.Open(fs.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	for line := 1; ; line++ {
+		data, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			// a line without a newline is a write cut short by a crash
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var rec counterRecord
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return fmt.Errorf("%s:%d: %v", fs.path, line, err)
+		}
+		fs.counters[rec.Name] = rec.Value
+	}
+}
+
+func (fs *fileStorage) compact() error {
+	tmp, err := os.CreateTemp(filepath.Dir(fs.path), filepath.Base(fs.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	for name, value := range fs.counters {
+		if err := writeCounterRecord(w, name, value); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), fs.path)
+}
+
+func writeCounterRecord(w io.Writer, name string, value int64) error {
+	data, err := json.Marshal(counterRecord{Name: name, Value: value})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+
+	return err
+}
+
+func (fs *fileStorage) Add(name string, delta int64) (int64, error) {
+	fs.mux.Lock()
+	defer fs.mux.Unlock()
+
+	if fs.f == nil {
+		return 0, os.ErrClosed
+	}
+
+	value, err := addCounter(fs.counters[name], delta)
+	if err != nil {
+		return value, err
+	}
+	// on disk first, so that a failed write changes nothing
+	if err := writeCounterRecord(fs.f, name, value); err != nil {
+		return fs.counters[name], err
+	}
+	fs.counters[name] = value
+
+	return value, nil
+}
+
+func (fs *fileStorage) Close() error {
+	fs.mux.Lock()
+	defer fs.mux.Unlock()
+
+	if fs.f == nil {
+		return nil
+	}
+	err := fs.f.Close()
+	fs.f = nil
+
+	return err
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	s := NewMemoryStorage()
+
+	for _, delta := range []int64{5, -2} {
+		if _, err := s.Add("a", delta); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if _, err := s.Add("max", math.MaxInt64); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v, err := s.Add("max", 1); err != errCounterOverflow || v != math.MaxInt64 {
+		t.Fatalf("expected overflow, got %d %v", v, err)
+	}
+
+	values, err := s.Get("a", "missing", "max")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []int64{3, 0, math.MaxInt64}; !reflect.DeepEqual(values, expected) {
+		t.Fatalf("values dont match\nhave %v\nwant %v", values, expected)
+	}
+}
+
+func TestFileStorage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counters")
+
+	s, err := OpenFileStorage(path)
+	if err != nil {
+		t.Fatalf("cant open storage: %v", err)
+	}
+	for _, name := range []string{"a", "b", "a", "a"} {
+		if _, err := s.Add(name, 1); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	s.Close()
+	if _, err := s.Add("a", 1); err == nil {
+		t.Fatalf("expected error after close")
+	}
+
+	// a record cut short by a crash is skipped
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("cant open file: %v", err)
+	}
+	f.WriteString(`{"name":"a","val`)
+	f.Close()
+
+	s, err = OpenFileStorage(path)
+	if err != nil {
+		t.Fatalf("cant reopen storage: %v", err)
+	}
+	defer s.Close()
+
+	values, err := s.Get("a", "b")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []int64{3, 1}; !reflect.DeepEqual(values, expected) {
+		t.Fatalf("values dont match\nhave %v\nwant %v", values, expected)
+	}
+
+	// compacted to one line per counter
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("cant read file: %v", err)
+	}
+	if len(data) != len(`{"name":"a","value":3}`+"\n"+`{"name":"b","value":1}`+"\n") {
+		t.Fatalf("file is not compacted:\n%s", data)
+	}
+
+	if err := os.WriteFile(path, []byte("garbage\n"), 0644); err != nil {
+		t.Fatalf("cant write file: %v", err)
+	}
+	if _, err := OpenFileStorage(path); err == nil {
+		t.Fatalf("expected error for a corrupted file")
+	}
+}
+
+func TestBiz(t *testing.T) {
+	ctx, finish := context.WithCancel(context.Background())
+	err := StartMyMicroservice(ctx, listenAddr, ACLData)
+	if err != nil {
+		t.Fatalf("cant start server initial: %v", err)
+	}
+	wait(1)
+	defer func() {
+		finish()
+		wait(1)
+	}()
+
+	conn := getGrpcConn(t)
+	defer conn.Close()
+
+	biz := NewBizClient(conn)
+	user := getConsumerCtx("biz_admin")
+
+	for _, d := range []*CounterDelta{{Name: "hits"}, {Name: "hits", Delta: 10}, {Name: "misses", Delta: -1}} {
+		if _, err := biz.Add(user, d); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	_, err = biz.Add(user, &CounterDelta{Name: "hits", Delta: math.MaxInt64})
+	if code := grpc.Code(err); code != codes.OutOfRange {
+		t.Fatalf("expected OutOfRange, got %v", err)
+	}
+
+	c, err := biz.Check(user, &CounterKey{Name: "hits"})
+	if err != nil || c.Name != "hits" || c.Value != 11 {
+		t.Fatalf("expected hits=11, got %v %v", c, err)
+	}
+
+	res, err := biz.Test(user, &CounterBatch{Expected: []*Counter{
+		{Name: "hits", Value: 11},
+		{Name: "misses", Value: 0},
+		{Name: "never", Value: 0},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Ok || len(res.Mismatched) != 1 || res.Mismatched[0].Name != "misses" || res.Mismatched[0].Value != -1 {
+		t.Fatalf("expected misses=-1 to mismatch, got %v", res)
+	}
+
+	// clients of the old API send Nothing and work with the unnamed counter
+	if err := conn.Invoke(user, "/main.Biz/Add", &Nothing{}, &Nothing{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c, err := biz.Check(user, &CounterKey{}); err != nil || c.Value != 1 {
+		t.Fatalf("expected unnamed counter to be 1, got %v %v", c, err)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestSubsOverflow checks that Notify never blocks on a stalled subscriber
+// and that every overflow policy accounts for the events it has lost
+func TestSubsOverflow(t *testing.T) {
+	notify := func(subs *EventSubs, methods ...string) {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for _, m := range methods {
+				subs.Notify(&Event{Method: m})
+			}
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("Notify blocked on a full subscriber")
+		}
+	}
+	drain := func(sub *Subscription) []string {
+		methods := []string{}
+		for {
+			select {
+			case e, ok := <-sub.Events:
+				if !ok {
+					return methods
+				}
+				methods = append(methods, e.Method)
+			default:
+				return methods
+			}
+		}
+	}
+
+	subs := newEventSubs(2, DropOldest)
+	sub := subs.NewSub(nil)
+	notify(subs, "a", "b", "c", "d")
+	if have := drain(sub); !reflect.DeepEqual(have, []string{"c", "d"}) {
+		t.Fatalf("drop oldest: unexpected events %v", have)
+	}
+	if dropped := sub.TakeDropped(); dropped != 2 {
+		t.Fatalf("drop oldest: expected 2 dropped, got %d", dropped)
+	}
+	if dropped := sub.TakeDropped(); dropped != 0 {
+		t.Fatalf("drop oldest: dropped counter was not reset, got %d", dropped)
+	}
+
+	subs = newEventSubs(2, DropNewest)
+	sub = subs.NewSub(nil)
+	notify(subs, "a", "b", "c", "d")
+	if have := drain(sub); !reflect.DeepEqual(have, []string{"a", "b"}) {
+		t.Fatalf("drop newest: unexpected events %v", have)
+	}
+	if dropped := sub.TakeDropped(); dropped != 2 {
+		t.Fatalf("drop newest: expected 2 dropped, got %d", dropped)
+	}
+
+	subs = newEventSubs(2, Disconnect)
+	slow := subs.NewSub(nil)
+	fast := subs.NewSub(nil)
+	notify(subs, "a", "b")
+	drain(fast)
+	notify(subs, "c")
+	if have := drain(slow); !reflect.DeepEqual(have, []string{"a", "b"}) {
+		t.Fatalf("disconnect: unexpected events %v", have)
+	}
+	if _, ok := <-slow.Events; ok {
+		t.Fatalf("disconnect: expected slow subscriber to be closed")
+	}
+	if code := status.Code(slow.Err()); code != codes.ResourceExhausted {
+		t.Fatalf("disconnect: expected ResourceExhausted, got %v", code)
+	}
+	if have := drain(fast); !reflect.DeepEqual(have, []string{"c"}) {
+		t.Fatalf("disconnect: fast subscriber got %v", have)
+	}
+	subs.RemoveSub(slow.ID)
+	subs.RemoveSub(fast.ID)
+	if fast.Err() != nil {
+		t.Fatalf("disconnect: unexpected error for fast subscriber: %v", fast.Err())
+	}
+}
+
+type blockingLogStream struct {
+	Admin_LoggingServer
+	sent chan *Event
+}
+
+func (s *blockingLogStream) Send(e *Event) error {
+	s.sent <- e
+	return nil
+}
+
+func (s *blockingLogStream) Context() context.Context {
+	return context.Background()
+}
+
+// TestLoggingDropped checks that a stream which lagged behind learns how many
+// events it has missed
+func TestLoggingDropped(t *testing.T) {
+	subs := newEventSubs(1, DropNewest)
+	srv := &blockingLogStream{sent: make(chan *Event)}
+	done := make(chan error)
+	go func() {
+		done <- NewAdminServer(subs, nil, nil).Logging(&LogFilter{}, srv)
+	}()
+	wait(1)
+
+	// first event gets stuck in Send, second one waits in the buffer, the rest are lost
+	subs.Notify(&Event{Method: "1"})
+	wait(1)
+	for _, m := range []string{"2", "3", "4", "5"} {
+		subs.Notify(&Event{Method: m})
+	}
+
+	if evt := <-srv.sent; evt.Method != "1" || evt.Dropped != 0 {
+		t.Fatalf("expected event 1 with nothing dropped, got %v with %d", evt.Method, evt.Dropped)
+	}
+	if evt := <-srv.sent; evt.Method != "2" || evt.Dropped != 3 {
+		t.Fatalf("expected event 2 with 3 dropped, got %v with %d", evt.Method, evt.Dropped)
+	}
+
+	subs.Close(nil)
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
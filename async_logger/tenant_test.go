@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const tenantACLData string = `{
+	"consumers": {
+		"a_user":  {"tenant": "team_a", "allow": ["/main.Biz/Check", "/main.Biz/Add"]},
+		"a_admin": {"tenant": "team_a", "allow": ["/main.Admin/*"]},
+		"b_user":  {"tenant": "team_b", "allow": ["/main.Biz/Check"]},
+		"b_admin": {"tenant": "team_b", "inherits": ["a_admin"]},
+		"root":    {"tenant": "ops", "role": "super_admin", "allow": ["/main.Admin/*"]},
+		"shared":  {"allow": ["/main.Biz/Check"]}
+	}
+}`
+
+func TestTenants(t *testing.T) {
+	ctx, finish := context.WithCancel(context.Background())
+	err := StartMyMicroservice(ctx, listenAddr, tenantACLData)
+	if err != nil {
+		t.Fatalf("cant start server initial: %v", err)
+	}
+	wait(1)
+	defer func() {
+		finish()
+		wait(1)
+	}()
+
+	conn := getGrpcConn(t)
+	defer conn.Close()
+
+	biz := NewBizClient(conn)
+	adm := NewAdminClient(conn)
+
+	var streams []Admin_LoggingClient
+	for _, consumer := range []string{"a_admin", "b_admin", "root"} {
+		stream, err := adm.Logging(getConsumerCtx(consumer), &LogFilter{})
+		if err != nil {
+			t.Fatalf("cant open log stream of %s: %v", consumer, err)
+		}
+		streams = append(streams, stream)
+		wait(1)
+	}
+
+	statStream, err := adm.Statistics(getConsumerCtx("a_admin"), &StatInterval{IntervalSeconds: 1})
+	if err != nil {
+		t.Fatalf("cant open stat stream: %v", err)
+	}
+	wait(1)
+
+	// a tenant can neither change the shared ACL nor look at the server as a whole
+	_, err = adm.UpdateACL(getConsumerCtx("a_admin"), &AclDocument{Json: tenantACLData})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied for a tenant admin, got %v", err)
+	}
+	serverStream, err := adm.Statistics(getConsumerCtx("a_admin"), &StatInterval{IntervalSeconds: 1, Mode: StatInterval_SERVER})
+	if err == nil {
+		_, err = serverStream.Recv()
+	}
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected PermissionDenied for server statistics, got %v", err)
+	}
+	if _, err := adm.UpdateACL(getConsumerCtx("root"), &AclDocument{Json: tenantACLData}); err != nil {
+		t.Fatalf("super admin cant update acl: %v", err)
+	}
+
+	biz.Check(getConsumerCtx("a_user"), &CounterKey{})
+	biz.Check(getConsumerCtx("b_user"), &CounterKey{})
+	biz.Check(getConsumerCtx("shared"), &CounterKey{})
+	biz.Add(getConsumerCtx("b_user"), &CounterDelta{})
+
+	stat, err := statStream.Recv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedByConsumer := map[string]uint64{"a_admin": 2, "a_user": 1}
+	if !reflect.DeepEqual(stat.ByConsumer, expectedByConsumer) {
+		t.Fatalf("stat of team_a dont match\nhave %v\nwant %v", stat.ByConsumer, expectedByConsumer)
+	}
+
+	// the streams end with the server, everything sent before is still there
+	finish()
+	wait(1)
+
+	var logs [][]string
+	for _, stream := range streams {
+		var lines []string
+		for {
+			e, err := stream.Recv()
+			if err != nil {
+				break
+			}
+			line := e.Consumer + " " + e.Method + " " + e.Tenant
+			if e.AclChange != nil {
+				line += " acl"
+			}
+			lines = append(lines, strings.Join(strings.Fields(line), " "))
+		}
+		logs = append(logs, lines)
+	}
+
+	expected := [][]string{
+		{
+			"a_admin /main.Admin/Statistics team_a",
+			"a_admin /main.Admin/UpdateACL team_a",
+			"a_admin /main.Admin/Statistics team_a",
+			"a_user /main.Biz/Check team_a",
+		},
+		{
+			"b_user /main.Biz/Check team_b",
+			"b_user /main.Biz/Add team_b",
+		},
+		{
+			"a_admin /main.Admin/Statistics team_a",
+			"a_admin /main.Admin/UpdateACL team_a",
+			"a_admin /main.Admin/Statistics team_a",
+			"root /main.Admin/UpdateACL ops",
+			"root /main.Admin/UpdateACL acl",
+			"a_user /main.Biz/Check team_a",
+			"b_user /main.Biz/Check team_b",
+			"shared /main.Biz/Check",
+			"b_user /main.Biz/Add team_b",
+		},
+	}
+	if !reflect.DeepEqual(logs, expected) {
+		t.Fatalf("logs dont match\nhave %q\nwant %q", logs, expected)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"google.golang.org/grpc/metadata"
+	"strings"
+	"sync"
+	"time"
+)
+
+// traceparentHeader carries the W3C trace context,
+// see https://www.w3.org/TR/trace-context/#traceparent-header
+const traceparentHeader = "traceparent"
+
+// SpanContext identifies a span across process boundaries.
+type SpanContext struct {
+	TraceID string // 32 lowercase hex digits
+	SpanID  string // 16 lowercase hex digits
+	Sampled bool
+}
+
+// ParseTraceparent reads a version 00 traceparent header value.
+func ParseTraceparent(value string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 {
+		return SpanContext{}, fmt.Errorf("bad traceparent %q", value)
+	}
+
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	switch {
+	case !isHex(version, 2) || version == "ff":
+		return SpanContext{}, fmt.Errorf("bad traceparent version %q", version)
+	case version == "00" && len(parts) != 4:
+		return SpanContext{}, fmt.Errorf("bad traceparent %q", value)
+	case !isHex(traceID, 32) || traceID == strings.Repeat("0", 32):
+		return SpanContext{}, fmt.Errorf("bad trace id %q", traceID)
+	case !isHex(spanID, 16) || spanID == strings.Repeat("0", 16):
+		return SpanContext{}, fmt.Errorf("bad parent id %q", spanID)
+	case !isHex(flags, 2):
+		return SpanContext{}, fmt.Errorf("bad trace flags %q", flags)
+	}
+
+	flagBits, _ := hex.DecodeString(flags)
+
+	return SpanContext{TraceID: traceID, SpanID: spanID, Sampled: flagBits[0]&1 == 1}, nil
+}
+
+func isHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Traceparent formats sc as a traceparent header value.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+
+	return "00-" + sc.TraceID + "-" + sc.SpanID + "-" + flags
+}
+
+func randomHex(bytes int) string {
+	b := make([]byte, bytes)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("cant read random bytes: %v", err))
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// Span is one call handled by the server.
+type Span struct {
+	SpanContext
+	ParentSpanID string // empty for calls that started a new trace
+	Name         string // full gRPC method
+	Consumer     string
+	Host         string
+	Start        time.Time
+	End          time.Time
+	Code         string // gRPC status code
+}
+
+// SpanExporter receives every sampled span once it has ended. Export is
+// called from the goroutine of the call and should not block for long.
+type SpanExporter interface {
+	Export(span *Span)
+}
+
+// InMemoryExporter keeps exported spans, it is meant for tests.
+type InMemoryExporter struct {
+	mux   *sync.Mutex
+	spans []*Span
+}
+
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{mux: &sync.Mutex{}}
+}
+
+func (e *InMemoryExporter) Export(span *Span) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	e.spans = append(e.spans, span)
+}
+
+// Spans returns the spans exported so far in the order they ended.
+func (e *InMemoryExporter) Spans() []*Span {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	return append([]*Span{}, e.spans...)
+}
+
+// startSpan continues the trace from the incoming traceparent or starts
+// a new one when there is none or it is malformed.
+func startSpan(ctx context.Context, method string) *Span {
+	span := &Span{Name: method, Start: time.Now()}
+
+	if parent, ok := incomingTraceparent(ctx); ok {
+		span.TraceID = parent.TraceID
+		span.ParentSpanID = parent.SpanID
+		span.Sampled = parent.Sampled
+	} else {
+		span.TraceID = randomHex(16)
+		span.Sampled = true
+	}
+	span.SpanID = randomHex(8)
+
+	return span
+}
+
+func incomingTraceparent(ctx context.Context) (SpanContext, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return SpanContext{}, false
+	}
+	values := md.Get(traceparentHeader)
+	if len(values) != 1 {
+		return SpanContext{}, false
+	}
+
+	sc, err := ParseTraceparent(values[0])
+
+	return sc, err == nil
+}
+
+type spanCtxKey struct{}
+
+func contextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanCtxKey{}, span.SpanContext)
+}
+
+// SpanFromContext returns the span of the call being handled.
+func SpanFromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanCtxKey{}).(SpanContext)
+
+	return sc, ok
+}
+
+// InjectTraceparent makes outgoing calls made with the returned context
+// continue the trace of the call being handled.
+func InjectTraceparent(ctx context.Context) context.Context {
+	sc, ok := SpanFromContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, traceparentHeader, sc.Traceparent())
+}
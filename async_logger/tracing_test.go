@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	testTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testSpanID  = "00f067aa0ba902b7"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	sc, err := ParseTraceparent("00-" + testTraceID + "-" + testSpanID + "-01")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sc.TraceID != testTraceID || sc.SpanID != testSpanID || !sc.Sampled {
+		t.Fatalf("unexpected span context %+v", sc)
+	}
+	if have := sc.Traceparent(); have != "00-"+testTraceID+"-"+testSpanID+"-01" {
+		t.Fatalf("unexpected traceparent %s", have)
+	}
+
+	// later versions may append fields
+	sc, err = ParseTraceparent("cc-" + testTraceID + "-" + testSpanID + "-08-whatever")
+	if err != nil || sc.Sampled {
+		t.Fatalf("expected unsampled future version, got %+v %v", sc, err)
+	}
+
+	for idx, value := range []string{
+		"",
+		"00-" + testTraceID + "-" + testSpanID,
+		"00-" + testTraceID + "-" + testSpanID + "-01-extra",
+		"ff-" + testTraceID + "-" + testSpanID + "-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-" + testSpanID + "-01",
+		"00-00000000000000000000000000000000-" + testSpanID + "-01",
+		"00-" + testTraceID + "-0000000000000000-01",
+		"00-" + testTraceID + "-" + testSpanID + "-1",
+	} {
+		if _, err := ParseTraceparent(value); err == nil {
+			t.Errorf("[%d] expected error for %q", idx, value)
+		}
+	}
+}
+
+func TestTracePropagation(t *testing.T) {
+	acl, err := newAclAuth(ACLData)
+	if err != nil {
+		t.Fatalf("cant parse acl: %v", err)
+	}
+	subs := newEventSubs(16, DropOldest)
+	exporter := NewInMemoryExporter()
+	mw := newAuthMiddleware(newAclStore(acl, subs), subs, []Authenticator{MetadataAuthenticator{}}, exporter)
+
+	sub := subs.NewSub(&eventFilter{results: true})
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		"consumer", "biz_user",
+		"traceparent", "00-"+testTraceID+"-"+testSpanID+"-01",
+	))
+	var handlerSpan SpanContext
+	var outgoing metadata.MD
+	_, err = mw.unaryInterceptor(ctx, &CounterKey{}, &grpc.UnaryServerInfo{FullMethod: "/main.Biz/Check"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			handlerSpan, _ = SpanFromContext(ctx)
+			outgoing, _ = metadata.FromOutgoingContext(InjectTraceparent(ctx))
+			return &Counter{}, nil
+		})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if handlerSpan.TraceID != testTraceID || handlerSpan.SpanID == testSpanID || len(handlerSpan.SpanID) != 16 {
+		t.Fatalf("handler got unexpected span %+v", handlerSpan)
+	}
+	if tp := outgoing.Get("traceparent"); len(tp) != 1 || tp[0] != handlerSpan.Traceparent() {
+		t.Fatalf("expected outgoing traceparent of the handler span, got %v", tp)
+	}
+
+	for _, e := range []*Event{<-sub.Events, <-sub.Events} {
+		if e.TraceId != testTraceID || e.SpanId != handlerSpan.SpanID {
+			t.Fatalf("unexpected trace on event %v", e)
+		}
+	}
+
+	spans := exporter.Spans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 exported span, got %d", len(spans))
+	}
+	if s := spans[0]; s.SpanContext != handlerSpan || s.ParentSpanID != testSpanID ||
+		s.Name != "/main.Biz/Check" || s.Consumer != "biz_user" || s.Code != "OK" || s.End.Before(s.Start) {
+		t.Fatalf("unexpected span %+v", s)
+	}
+
+	// a denied call without traceparent starts a new trace and is exported
+	// too, an unsampled one is only recorded on events
+	deniedCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("consumer", "biz_user"))
+	_, err = mw.unaryInterceptor(deniedCtx, &CounterBatch{}, &grpc.UnaryServerInfo{FullMethod: "/main.Biz/Test"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			t.Fatalf("handler of a denied call was called")
+			return nil, nil
+		})
+	if err == nil {
+		t.Fatalf("expected call to be denied")
+	}
+	unsampledCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		"consumer", "biz_user",
+		"traceparent", "00-"+testTraceID+"-"+testSpanID+"-00",
+	))
+	mw.unaryInterceptor(unsampledCtx, &CounterKey{}, &grpc.UnaryServerInfo{FullMethod: "/main.Biz/Check"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return &Counter{}, nil
+		})
+
+	denied := <-sub.Events
+	if !isHex(denied.TraceId, 32) || denied.TraceId == testTraceID {
+		t.Fatalf("expected a new trace, got %q", denied.TraceId)
+	}
+	<-sub.Events
+	if unsampled := <-sub.Events; unsampled.TraceId != testTraceID {
+		t.Fatalf("expected trace of the unsampled call to be recorded, got %v", unsampled)
+	}
+
+	spans = exporter.Spans()
+	if len(spans) != 2 || spans[1].TraceID != denied.TraceId || spans[1].ParentSpanID != "" || spans[1].Code != "Unauthenticated" {
+		t.Fatalf("unexpected spans %+v", spans)
+	}
+}
@@ -1,14 +1,15 @@
 package main
 
 import (
+	"crypto/subtle"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"slices"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 type Row struct {
@@ -26,14 +27,17 @@ func NewRow(l int) Row {
 type RowData map[string]interface{}
 
 type ResponseItems struct {
-	Tables  []string  `json:"tables,omitempty"`
-	Record  RowData   `json:"record,omitempty"`
-	Records []RowData `json:"records,omitempty"`
-	Updated *int64    `json:"updated,omitempty"`
-	Deleted *int64    `json:"deleted,omitempty"`
+	Tables     []string  `json:"tables,omitempty"`
+	Schema     *Schema   `json:"schema,omitempty"`
+	Record     RowData   `json:"record,omitempty"`
+	Records    []RowData `json:"records,omitempty"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	Total      *int64    `json:"total,omitempty"`
+	Updated    *int64    `json:"updated,omitempty"`
+	Deleted    *int64    `json:"deleted,omitempty"`
 }
 
-type ResponseID map[string]*int64
+type ResponseID map[string]interface{}
 
 type ResponseError struct {
 	Error      string
@@ -46,7 +50,14 @@ type Resp struct {
 }
 
 type DBExplorer struct {
-	DB *sql.DB
+	DB      *sql.DB
+	Dialect Dialect
+	// ReloadToken is asked as "Authorization: Bearer <token>" by
+	// POST /_schema/reload, which is off while the token is empty.
+	ReloadToken string
+
+	mux    *sync.RWMutex
+	schema *Schema
 }
 
 func writeResponse(w http.ResponseWriter, resp interface{}, err *ResponseError) {
@@ -75,45 +86,130 @@ func (dbe *DBExplorer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	fmt.Printf("%7s %s\n", r.Method, r.URL.Path)
 
-	if method == http.MethodGet {
-		urlParts := strings.Split(url, "/")
+	urlParts := strings.Split(url, "/")
+	if len(urlParts) > 3 {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if urlParts[1] == schemaPath {
+		dbe.schemaHandler(w, r, urlParts)
+		return
+	}
 
+	// a request sees the same schema from start to end, even if it is reloaded meanwhile
+	qb := newQueryBuilder(dbe.Schema(), dbe.Dialect)
+
+	// the table is checked before anything is asked from the database
+	if len(urlParts[1]) != 0 {
+		if _, _, errResp := qb.table(urlParts[1]); errResp != nil {
+			writeResponse(w, nil, errResp)
+			return
+		}
+	}
+
+	if method == http.MethodGet {
 		switch len(urlParts) {
 		case 2:
 			if len(urlParts[1]) == 0 {
-				GetTablesHandler(dbe.DB)(w, r)
+				GetTablesHandler(qb.schema)(w, r)
 			} else {
-				GetRowsHandler(dbe.DB)(w, r)
+				GetRowsHandler(dbe.DB, qb)(w, r)
 			}
 		case 3:
-			GetRowsByIDHandler(dbe.DB)(w, r)
-		default:
-			w.WriteHeader(http.StatusMethodNotAllowed)
+			GetRowsByIDHandler(dbe.DB, qb)(w, r)
 		}
+	} else if len(urlParts[1]) == 0 {
+		w.WriteHeader(http.StatusMethodNotAllowed)
 	} else if method == http.MethodPut {
-		PutRowHandler(dbe.DB)(w, r)
+		PutRowHandler(dbe.DB, qb)(w, r)
+	} else if len(urlParts) != 3 || len(urlParts[2]) == 0 {
+		w.WriteHeader(http.StatusMethodNotAllowed)
 	} else if method == http.MethodPost {
-		PostRowHandler(dbe.DB)(w, r)
+		PostRowHandler(dbe.DB, qb)(w, r)
 	} else if method == http.MethodDelete {
-		DeleteRowHandler(dbe.DB)(w, r)
+		DeleteRowHandler(dbe.DB, qb)(w, r)
 	} else {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 	}
 }
 
 func NewDbExplorer(db *sql.DB) (http.Handler, error) {
-	return &DBExplorer{DB: db}, nil
+	return NewDbExplorerWithDialect(db, DetectDialect(db))
+}
+
+func NewDbExplorerWithDialect(db *sql.DB, dialect Dialect) (*DBExplorer, error) {
+	schema, err := LoadSchema(db, dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DBExplorer{DB: db, Dialect: dialect, mux: &sync.RWMutex{}, schema: schema}, nil
+}
+
+// schemaPath is where the loaded schema is shown and reloaded from, a table
+// of that name is not reachable through the explorer.
+const schemaPath = "_schema"
+
+func (dbe *DBExplorer) Schema() *Schema {
+	dbe.mux.RLock()
+	defer dbe.mux.RUnlock()
+
+	return dbe.schema
+}
+
+// ReloadSchema loads the schema again, for when the tables have changed. The
+// old schema stays if loading fails.
+func (dbe *DBExplorer) ReloadSchema() (*Schema, error) {
+	schema, err := LoadSchema(dbe.DB, dbe.Dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	dbe.mux.Lock()
+	dbe.schema = schema
+	dbe.mux.Unlock()
+
+	return schema, nil
+}
+
+// schemaHandler serves GET /_schema with the schema in use and
+// POST /_schema/reload to load it again, for those with the ReloadToken.
+func (dbe *DBExplorer) schemaHandler(w http.ResponseWriter, r *http.Request, urlParts []string) {
+	if r.Method == http.MethodGet && len(urlParts) == 2 {
+		writeResponse(w, &ResponseItems{Schema: dbe.Schema()}, nil)
+		return
+	}
+	if r.Method != http.MethodPost || len(urlParts) != 3 || urlParts[2] != "reload" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if dbe.ReloadToken == "" {
+		writeResponse(w, nil, &ResponseError{Error: "schema reload is disabled", StatusCode: http.StatusForbidden})
+		return
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(dbe.ReloadToken)) != 1 {
+		writeResponse(w, nil, &ResponseError{Error: "bad reload token", StatusCode: http.StatusUnauthorized})
+		return
+	}
+
+	schema, err := dbe.ReloadSchema()
+	if err != nil {
+		writeResponse(w, nil, &ResponseError{Error: err.Error()})
+		return
+	}
+	writeResponse(w, &ResponseItems{Schema: schema}, nil)
 }
 
-func GetTablesHandler(db *sql.DB) http.HandlerFunc {
+func GetTablesHandler(schema *Schema) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		res, err := GetTables(db)
-		writeResponse(w, &ResponseItems{Tables: res}, err)
+		writeResponse(w, &ResponseItems{Tables: schema.TableNames()}, nil)
 	}
 }
 
-func GetTables(db *sql.DB) ([]string, *ResponseError) {
-	rows, err := db.Query("SHOW TABLES")
+func GetTables(db *sql.DB, dialect Dialect) ([]string, *ResponseError) {
+	rows, err := db.Query(dialect.TablesQuery())
 	if err != nil {
 		return nil, &ResponseError{Error: err.Error()}
 	}
@@ -131,56 +227,119 @@ func GetTables(db *sql.DB) ([]string, *ResponseError) {
 	return tables, nil
 }
 
-func GetRowsHandler(db *sql.DB) http.HandlerFunc {
+func GetRowsHandler(db *sql.DB, qb *queryBuilder) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		urlPart := strings.Split(r.URL.Path, "/")[1]
 		table := strings.Split(urlPart, "?")[0]
 
-		limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
-		if err != nil {
-			limit = 5
+		t, _, errResp := qb.table(table)
+		if errResp != nil {
+			writeResponse(w, nil, errResp)
+			return
 		}
 
-		offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
-		if err != nil {
-			offset = 0
+		rq, errResp := parseRowsQuery(r.URL.Query(), t)
+		if errResp != nil {
+			writeResponse(w, nil, errResp)
+			return
 		}
 
-		res, errResp := GetRows(db, table, limit, offset)
-		writeResponse(w, &ResponseItems{Records: res}, errResp)
+		res, errResp := GetRows(db, qb, table, rq)
+		writeResponse(w, res, errResp)
 	}
 }
 
-func GetRows(db *sql.DB, table string, limit, offset int) ([]RowData, *ResponseError) {
-	query := fmt.Sprintf("SELECT * FROM %s LIMIT ? OFFSET ?", table)
-	rows, err := db.Query(query, limit, offset)
+func GetRows(db *sql.DB, qb *queryBuilder, table string, rq *rowsQuery) (*ResponseItems, *ResponseError) {
+	rs, errResp := qb.selectRows(table, rq)
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	rows, err := db.Query(rs.Query, rs.Args...)
 	if err != nil {
-		return nil, &ResponseError{Error: "unknown table", StatusCode: http.StatusNotFound}
+		return nil, &ResponseError{Error: err.Error()}
 	}
 	defer rows.Close()
 
-	return unpackRows(rows)
+	records, errResp := unpackRows(rows)
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	res := &ResponseItems{Records: records}
+	if len(records) > rq.Limit {
+		res.Records = records[:rq.Limit]
+		if rq.ByCursor && rq.Limit > 0 {
+			res.NextCursor = encodeCursor(rs.Keys, res.Records[rq.Limit-1])
+		}
+	}
+	for _, record := range res.Records {
+		for _, column := range rs.Extra {
+			delete(record, column)
+		}
+	}
+
+	if rq.Count != "" {
+		total, errResp := CountRows(db, qb, table, rq)
+		if errResp != nil {
+			return nil, errResp
+		}
+		res.Total = &total
+	}
+
+	return res, nil
 }
 
-func GetRowsByIDHandler(db *sql.DB) http.HandlerFunc {
+// CountRows is the number of rows that match the filters of rq. An estimate
+// comes from the table statistics, so with filters it is counted anyway.
+func CountRows(db *sql.DB, qb *queryBuilder, table string, rq *rowsQuery) (int64, *ResponseError) {
+	if rq.Count == "estimate" && len(rq.Filters) == 0 {
+		query, args, errResp := qb.estimateRows(table)
+		if errResp != nil {
+			return 0, errResp
+		}
+
+		var estimate sql.NullInt64
+		if query != "" {
+			if err := db.QueryRow(query, args...).Scan(&estimate); err != nil && err != sql.ErrNoRows {
+				return 0, &ResponseError{Error: err.Error()}
+			}
+		}
+		if estimate.Valid {
+			return estimate.Int64, nil
+		}
+	}
+
+	query, args, errResp := qb.countRows(table, rq)
+	if errResp != nil {
+		return 0, errResp
+	}
+
+	var total int64
+	if err := db.QueryRow(query, args...).Scan(&total); err != nil {
+		return 0, &ResponseError{Error: err.Error()}
+	}
+	return total, nil
+}
+
+func GetRowsByIDHandler(db *sql.DB, qb *queryBuilder) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		urlParts := strings.Split(r.URL.Path, "/")
 		table := strings.Split(urlParts[1], "?")[0]
 		id := strings.Split(urlParts[2], "?")[0]
 
-		res, err := GetRowsById(db, table, id)
+		res, err := GetRowsById(db, qb, table, id)
 		writeResponse(w, &ResponseItems{Record: res}, err)
 	}
 }
 
-func GetRowsById(db *sql.DB, table, id string) (RowData, *ResponseError) {
-	idColumnName, errResp := getIdColumnName(db, table)
+func GetRowsById(db *sql.DB, qb *queryBuilder, table, id string) (RowData, *ResponseError) {
+	query, args, errResp := qb.selectRow(table, id)
 	if errResp != nil {
 		return nil, errResp
 	}
 
-	query := fmt.Sprintf("SELECT * FROM %s WHERE %s = ?", table, idColumnName)
-	rows, err := db.Query(query, id)
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		return nil, &ResponseError{Error: err.Error()}
 	}
@@ -198,7 +357,7 @@ func GetRowsById(db *sql.DB, table, id string) (RowData, *ResponseError) {
 	}
 }
 
-func PutRowHandler(db *sql.DB) http.HandlerFunc {
+func PutRowHandler(db *sql.DB, qb *queryBuilder) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		urlPart := strings.Split(r.URL.Path, "/")[1]
 		table := strings.Split(urlPart, "?")[0]
@@ -210,69 +369,64 @@ func PutRowHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		idColumnName, errResp := getIdColumnName(db, table)
+		t, _, errResp := qb.table(table)
 		if errResp != nil {
 			writeResponse(w, nil, errResp)
 			return
 		}
 
+		idColumnName := t.IDColumn()
 		delete(rowData, idColumnName)
 
-		res, err := CreateRow(db, table, rowData)
-		writeResponse(w, &ResponseID{idColumnName: &res}, err)
+		res, err := CreateRow(db, qb, table, rowData)
+		writeResponse(w, &ResponseID{idColumnName: res}, err)
 	}
 }
 
-func CreateRow(db *sql.DB, table string, rowData map[string]interface{}) (int64, *ResponseError) {
-	query := fmt.Sprintf("INSERT INTO %s", table)
-
-	columnTypes, nullColumns, errResp := getTableTypes(db, table)
+// CreateRow returns the id of the new row, an int64 or for a primary key
+// of another kind what the database returned for it.
+func CreateRow(db *sql.DB, qb *queryBuilder, table string, rowData map[string]interface{}) (interface{}, *ResponseError) {
+	t, _, errResp := qb.table(table)
 	if errResp != nil {
 		return 0, errResp
 	}
 
-	for colName, isNullable := range nullColumns {
-		if _, ok := rowData[colName]; !ok {
-			if !isNullable {
-				if columnTypes[colName] == "int" {
-					rowData[colName] = 0
-				} else {
-					rowData[colName] = ""
-				}
-			}
+	// the database fills in the rest
+	for _, col := range t.Columns {
+		if _, ok := rowData[col.Name]; ok || col.Nullable || col.Default != nil || col.AutoIncrement {
+			continue
+		}
+		if col.Kind == "int" || col.Kind == "float" {
+			rowData[col.Name] = 0
+		} else {
+			rowData[col.Name] = ""
 		}
 	}
 
-	columnNames, errResp := getTableColumns(db, table)
+	query, args, returning, errResp := qb.insertRow(table, rowData)
 	if errResp != nil {
 		return 0, errResp
 	}
 
-	for colName := range rowData {
-		if !slices.Contains(columnNames, colName) {
-			delete(rowData, colName)
+	if returning {
+		var id interface{}
+		if err := db.QueryRow(query, args...).Scan(&id); err != nil {
+			return 0, &ResponseError{Error: err.Error()}
 		}
+		// insertRow only asks for an id that is a column
+		col, _ := t.Column(t.IDColumn())
+		return idValue(id, col.Kind), nil
 	}
 
-	paramRow := make([]string, len(rowData))
-	valueRow := make([]interface{}, len(rowData))
-	questionRow := make([]string, len(rowData))
-
-	i := 0
-	for colName, val := range rowData {
-		paramRow[i] = colName
-		valueRow[i] = val
-		questionRow[i] = "?"
-		i++
-	}
-
-	query += fmt.Sprintf(" (%s) VALUES (%s)", strings.Join(paramRow, ", "), strings.Join(questionRow, ", "))
-
-	res, err := db.Exec(query, valueRow...)
+	res, err := db.Exec(query, args...)
 	if err != nil {
 		return 0, &ResponseError{Error: err.Error()}
 	}
 
+	// without a primary key there is no id to tell
+	if t.IDColumn() == "" {
+		return 0, nil
+	}
 	id, err := res.LastInsertId()
 	if err != nil {
 		return 0, &ResponseError{Error: err.Error()}
@@ -280,7 +434,22 @@ func CreateRow(db *sql.DB, table string, rowData map[string]interface{}) (int64,
 	return id, nil
 }
 
-func PostRowHandler(db *sql.DB) http.HandlerFunc {
+// idValue converts an id scanned without a type, drivers hand out text and
+// numerics as bytes.
+func idValue(id interface{}, kind string) interface{} {
+	b, ok := id.([]byte)
+	if !ok {
+		return id
+	}
+	if kind == "int" {
+		if n, err := strconv.ParseInt(string(b), 10, 64); err == nil {
+			return n
+		}
+	}
+	return string(b)
+}
+
+func PostRowHandler(db *sql.DB, qb *queryBuilder) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		urlParts := strings.Split(r.URL.Path, "/")
 		table := strings.Split(urlParts[1], "?")[0]
@@ -292,40 +461,36 @@ func PostRowHandler(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		err = validateRowData(db, table, rowData)
+		t, _, err := qb.table(table)
 		if err != nil {
 			writeResponse(w, nil, err)
 			return
 		}
 
-		idColumnName, errResp := getIdColumnName(db, table)
-		if errResp != nil {
-			writeResponse(w, nil, errResp)
+		err = validateRowData(t, rowData)
+		if err != nil {
+			writeResponse(w, nil, err)
 			return
 		}
 
+		idColumnName := t.IDColumn()
 		if _, ok := rowData[idColumnName]; ok {
 			writeResponse(w, nil, &ResponseError{Error: fmt.Sprintf("field %s have invalid type", idColumnName), StatusCode: http.StatusBadRequest})
 			return
 		}
 
-		res, err := UpdateRow(db, table, id, idColumnName, rowData)
+		res, err := UpdateRow(db, qb, table, id, rowData)
 		writeResponse(w, &ResponseItems{Updated: &res}, err)
 	}
 }
 
-func UpdateRow(db *sql.DB, table, id, idColumnName string, rowData map[string]interface{}) (int64, *ResponseError) {
-	updateRow := make([]string, len(rowData))
-
-	i := 0
-	for colName, val := range rowData {
-		updateRow[i] = fmt.Sprintf("%s = '%v'", colName, val)
-		i++
+func UpdateRow(db *sql.DB, qb *queryBuilder, table, id string, rowData map[string]interface{}) (int64, *ResponseError) {
+	query, args, errResp := qb.updateRow(table, id, rowData)
+	if errResp != nil {
+		return 0, errResp
 	}
 
-	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s = %s", table, strings.Join(updateRow, ", "), idColumnName, id)
-
-	res, err := db.Exec(query)
+	res, err := db.Exec(query, args...)
 	if err != nil {
 		return 0, &ResponseError{Error: err.Error()}
 	}
@@ -334,26 +499,24 @@ func UpdateRow(db *sql.DB, table, id, idColumnName string, rowData map[string]in
 	return r, nil
 }
 
-func DeleteRowHandler(db *sql.DB) http.HandlerFunc {
+func DeleteRowHandler(db *sql.DB, qb *queryBuilder) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		urlParts := strings.Split(r.URL.Path, "/")
 		table := strings.Split(urlParts[1], "?")[0]
 		id := strings.Split(urlParts[2], "?")[0]
 
-		res, err := DeleteRowById(db, table, id)
+		res, err := DeleteRowById(db, qb, table, id)
 		writeResponse(w, &ResponseItems{Deleted: &res}, err)
 	}
 }
 
-func DeleteRowById(db *sql.DB, table, id string) (int64, *ResponseError) {
-	idColumnName, errResp := getIdColumnName(db, table)
+func DeleteRowById(db *sql.DB, qb *queryBuilder, table, id string) (int64, *ResponseError) {
+	query, args, errResp := qb.deleteRow(table, id)
 	if errResp != nil {
 		return 0, errResp
 	}
 
-	query := fmt.Sprintf("DELETE FROM %s WHERE %s = ?", table, idColumnName)
-
-	res, err := db.Exec(query, id)
+	res, err := db.Exec(query, args...)
 	if err != nil {
 		return 0, &ResponseError{Error: err.Error()}
 	}
@@ -401,24 +564,6 @@ func unpackRows(rows *sql.Rows) ([]RowData, *ResponseError) {
 	return res, nil
 }
 
-func getIdColumnName(db *sql.DB, table string) (string, *ResponseError) {
-	rows, err := db.Query("SELECT COLUMN_NAME FROM information_schema.KEY_COLUMN_USAGE WHERE TABLE_NAME = ? AND CONSTRAINT_NAME = 'PRIMARY'", table)
-	if err != nil {
-		return "", &ResponseError{Error: err.Error()}
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var id string
-		if err = rows.Scan(&id); err != nil {
-			return "", &ResponseError{Error: err.Error()}
-		}
-		return id, nil
-	}
-
-	return "", nil
-}
-
 func getRowData(body io.ReadCloser) (map[string]interface{}, *ResponseError) {
 	var rowData map[string]interface{}
 	if err := json.NewDecoder(body).Decode(&rowData); err != nil {
@@ -427,19 +572,15 @@ func getRowData(body io.ReadCloser) (map[string]interface{}, *ResponseError) {
 	return rowData, nil
 }
 
-func validateRowData(db *sql.DB, table string, rowData map[string]interface{}) *ResponseError {
-	tableTypes, nullTypes, err := getTableTypes(db, table)
-	if err != nil {
-		return err
-	}
-
+func validateRowData(t *Table, rowData map[string]interface{}) *ResponseError {
 	for colName, val := range rowData {
-		if _, ok := tableTypes[colName]; !ok {
+		col, ok := t.Column(colName)
+		if !ok {
 			return &ResponseError{Error: "field doesn't exist", StatusCode: http.StatusBadRequest}
 		}
 
 		if val == nil {
-			if nullTypes[colName] {
+			if col.Nullable {
 				continue
 			}
 			return &ResponseError{Error: fmt.Sprintf("field %s have invalid type", colName), StatusCode: http.StatusBadRequest}
@@ -447,11 +588,11 @@ func validateRowData(db *sql.DB, table string, rowData map[string]interface{}) *
 
 		switch val.(type) {
 		case float64:
-			if tableTypes[colName] != "int" {
+			if col.Kind != "int" && col.Kind != "float" {
 				return &ResponseError{Error: fmt.Sprintf("field %s have invalid type", colName), StatusCode: http.StatusBadRequest}
 			}
 		case string:
-			if !slices.Contains([]string{"text", "varchar"}, tableTypes[colName]) {
+			if col.Kind != "string" {
 				return &ResponseError{Error: fmt.Sprintf("field %s have invalid type", colName), StatusCode: http.StatusBadRequest}
 			}
 		default:
@@ -461,55 +602,3 @@ func validateRowData(db *sql.DB, table string, rowData map[string]interface{}) *
 
 	return nil
 }
-
-func getTableTypes(db *sql.DB, table string) (map[string]string, map[string]bool, *ResponseError) {
-	rows, err := db.Query("SELECT COLUMN_NAME, DATA_TYPE, IS_NULLABLE FROM information_schema.COLUMNS WHERE TABLE_NAME = ?", table)
-	if err != nil {
-		panic(err)
-	}
-	defer rows.Close()
-
-	types := make(map[string]string)
-	nulls := make(map[string]bool)
-	for rows.Next() {
-		var colName string
-		var dataType string
-		var isNullable string
-		if err = rows.Scan(&colName, &dataType, &isNullable); err != nil {
-			return nil, nil, &ResponseError{Error: err.Error()}
-		}
-		types[colName] = dataType
-		if isNullable == "YES" {
-			nulls[colName] = true
-		} else {
-			nulls[colName] = false
-		}
-	}
-
-	return types, nulls, nil
-}
-
-func getTableColumns(db *sql.DB, table string) ([]string, *ResponseError) {
-	var dbName string
-	err := db.QueryRow("SELECT DATABASE()").Scan(&dbName)
-	if err != nil {
-		return nil, &ResponseError{Error: err.Error()}
-	}
-
-	rows, err := db.Query("SELECT COLUMN_NAME FROM information_schema.COLUMNS WHERE TABLE_NAME = ? AND TABLE_SCHEMA = ?", table, dbName)
-	if err != nil {
-		return nil, &ResponseError{Error: err.Error()}
-	}
-	defer rows.Close()
-
-	columns := make([]string, 0)
-	for rows.Next() {
-		var colName string
-		if err = rows.Scan(&colName); err != nil {
-			return nil, &ResponseError{Error: err.Error()}
-		}
-		columns = append(columns, colName)
-	}
-
-	return columns, nil
-}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"database/sql"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Dialect is what differs between the databases the explorer works with.
+// The schema queries answer in the same shape everywhere:
+//
+//	TablesQuery   table
+//	ColumnsQuery  table, column, type, YES or NO for nullable, default, extra
+//	KeysQuery     table, column, constraint, referenced table, referenced column
+//
+// where extra has auto_increment for the columns the database fills in, and
+// constraint is PRIMARY for the columns of the primary key, in their order.
+type Dialect interface {
+	Name() string
+	Quote(name string) string
+	// Placeholder is the placeholder of the n-th argument of a statement, from 1.
+	Placeholder(n int) string
+
+	TablesQuery() string
+	ColumnsQuery() string
+	KeysQuery() string
+	// Kind maps a column type to the kind values are checked by: int, float,
+	// string or empty when the explorer does not know the type.
+	Kind(dataType string) string
+
+	// DefaultValues follows INSERT INTO table when no column is given.
+	DefaultValues() string
+	// Returning is added to an INSERT to get the new id back, empty when the
+	// id comes from LastInsertId.
+	Returning(idColumn string) string
+	// EstimateQuery asks for the statistics on the size of table, empty
+	// when there are none.
+	EstimateQuery(table string) (string, []interface{})
+}
+
+var (
+	MySQL      Dialect = mysqlDialect{}
+	PostgreSQL Dialect = postgresDialect{}
+	SQLite     Dialect = sqliteDialect{}
+)
+
+// DetectDialect picks the dialect by the package of the driver of db, MySQL
+// when it is not known.
+func DetectDialect(db *sql.DB) Dialect {
+	t := reflect.TypeOf(db.Driver())
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return dialectOfPackage(t.PkgPath())
+}
+
+// dialectOfPackage knows lib/pq, pgx, mattn/go-sqlite3, modernc.org/sqlite
+// and the like by their import path.
+func dialectOfPackage(pkgPath string) Dialect {
+	switch pkgPath = strings.ToLower(pkgPath); {
+	case strings.HasSuffix(pkgPath, "/lib/pq"), strings.Contains(pkgPath, "/pgx"), strings.Contains(pkgPath, "postgres"):
+		return PostgreSQL
+	case strings.Contains(pkgPath, "sqlite"):
+		return SQLite
+	default:
+		return MySQL
+	}
+}
+
+func quoteWith(name, quote string) string {
+	return quote + strings.ReplaceAll(name, quote, quote+quote) + quote
+}
+
+var (
+	intTypes    = []string{"int", "integer", "tinyint", "smallint", "mediumint", "bigint", "int2", "int4", "int8", "serial", "smallserial", "bigserial"}
+	floatTypes  = []string{"float", "double", "double precision", "real", "decimal", "numeric", "float4", "float8"}
+	stringTypes = []string{"char", "varchar", "character", "character varying", "bpchar", "text", "tinytext", "mediumtext", "longtext", "enum", "set", "uuid", "citext"}
+)
+
+// kindOf is the kind of a type by its name, with the size and unsigned left out.
+func kindOf(dataType string) string {
+	dataType = strings.ToLower(dataType)
+	if i := strings.Index(dataType, "("); i >= 0 {
+		dataType = dataType[:i]
+	}
+	dataType = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(dataType), "unsigned"))
+
+	for kind, types := range map[string][]string{"int": intTypes, "float": floatTypes, "string": stringTypes} {
+		for _, t := range types {
+			if t == dataType {
+				return kind
+			}
+		}
+	}
+	return ""
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string { return "mysql" }
+
+func (mysqlDialect) Quote(name string) string { return quoteWith(name, "`") }
+
+func (mysqlDialect) Placeholder(n int) string { return "?" }
+
+func (mysqlDialect) TablesQuery() string { return "SHOW TABLES" }
+
+func (mysqlDialect) ColumnsQuery() string {
+	return "SELECT TABLE_NAME, COLUMN_NAME, DATA_TYPE, IS_NULLABLE, COLUMN_DEFAULT, EXTRA FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() ORDER BY TABLE_NAME, ORDINAL_POSITION"
+}
+
+func (mysqlDialect) KeysQuery() string {
+	return "SELECT TABLE_NAME, COLUMN_NAME, CONSTRAINT_NAME, REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME FROM information_schema.KEY_COLUMN_USAGE WHERE TABLE_SCHEMA = DATABASE() ORDER BY TABLE_NAME, CONSTRAINT_NAME, ORDINAL_POSITION"
+}
+
+func (mysqlDialect) Kind(dataType string) string { return kindOf(dataType) }
+
+func (mysqlDialect) DefaultValues() string { return "() VALUES ()" }
+
+func (mysqlDialect) Returning(idColumn string) string { return "" }
+
+func (mysqlDialect) EstimateQuery(table string) (string, []interface{}) {
+	return "SELECT TABLE_ROWS FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?", []interface{}{table}
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string { return "postgres" }
+
+func (postgresDialect) Quote(name string) string { return quoteWith(name, `"`) }
+
+func (postgresDialect) Placeholder(n int) string { return "$" + strconv.Itoa(n) }
+
+func (postgresDialect) TablesQuery() string {
+	return "SELECT table_name FROM information_schema.tables WHERE table_schema = current_schema() AND table_type = 'BASE TABLE' ORDER BY table_name"
+}
+
+func (postgresDialect) ColumnsQuery() string {
+	return "SELECT table_name, column_name, data_type, is_nullable, column_default, " +
+		"CASE WHEN is_identity = 'YES' OR column_default LIKE 'nextval(%' THEN 'auto_increment' ELSE '' END " +
+		"FROM information_schema.columns WHERE table_schema = current_schema() ORDER BY table_name, ordinal_position"
+}
+
+// KeysQuery reads pg_constraint, constraint names are only unique per table
+// and information_schema has no table to tell a foreign key by.
+func (postgresDialect) KeysQuery() string {
+	return "SELECT t.relname, a.attname, CASE WHEN c.contype = 'p' THEN 'PRIMARY' ELSE c.conname END, rt.relname, ra.attname " +
+		"FROM pg_constraint c " +
+		"JOIN pg_class t ON t.oid = c.conrelid " +
+		"CROSS JOIN LATERAL unnest(c.conkey, c.confkey) WITH ORDINALITY AS k(attnum, refattnum, pos) " +
+		"JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum " +
+		"LEFT JOIN pg_class rt ON rt.oid = c.confrelid " +
+		"LEFT JOIN pg_attribute ra ON ra.attrelid = c.confrelid AND ra.attnum = k.refattnum " +
+		"WHERE t.relnamespace = current_schema()::regnamespace AND c.contype IN ('p', 'f') " +
+		"ORDER BY t.relname, c.conname, k.pos"
+}
+
+func (postgresDialect) Kind(dataType string) string { return kindOf(dataType) }
+
+func (postgresDialect) DefaultValues() string { return "DEFAULT VALUES" }
+
+func (d postgresDialect) Returning(idColumn string) string { return " RETURNING " + d.Quote(idColumn) }
+
+// EstimateQuery reads pg_class, where a table that was never analyzed has -1.
+func (d postgresDialect) EstimateQuery(table string) (string, []interface{}) {
+	return "SELECT CASE WHEN reltuples < 0 THEN NULL ELSE reltuples::bigint END FROM pg_class WHERE oid = to_regclass($1)", []interface{}{d.Quote(table)}
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string { return "sqlite" }
+
+func (sqliteDialect) Quote(name string) string { return quoteWith(name, `"`) }
+
+func (sqliteDialect) Placeholder(n int) string { return "?" }
+
+func (sqliteDialect) TablesQuery() string {
+	return "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name"
+}
+
+// ColumnsQuery takes an INTEGER PRIMARY KEY as filled in, it is the rowid.
+func (sqliteDialect) ColumnsQuery() string {
+	return "SELECT m.name, p.name, p.type, CASE WHEN p.\"notnull\" = 0 AND p.pk = 0 THEN 'YES' ELSE 'NO' END, p.dflt_value, " +
+		"CASE WHEN p.pk = 1 AND upper(p.type) = 'INTEGER' AND (SELECT count(*) FROM pragma_table_info(m.name) WHERE pk > 0) = 1 THEN 'auto_increment' ELSE '' END " +
+		"FROM sqlite_master m JOIN pragma_table_info(m.name) p WHERE m.type = 'table' AND m.name NOT LIKE 'sqlite_%' ORDER BY m.name, p.cid"
+}
+
+func (sqliteDialect) KeysQuery() string {
+	return "SELECT t, c, k, rt, rc FROM (" +
+		"SELECT m.name AS t, p.name AS c, 'PRIMARY' AS k, NULL AS rt, NULL AS rc, p.pk AS pos FROM sqlite_master m JOIN pragma_table_info(m.name) p WHERE m.type = 'table' AND p.pk > 0 " +
+		"UNION ALL " +
+		"SELECT m.name, f.\"from\", 'fk_' || f.id, f.\"table\", f.\"to\", f.seq FROM sqlite_master m JOIN pragma_foreign_key_list(m.name) f WHERE m.type = 'table'" +
+		") ORDER BY t, k, pos"
+}
+
+// Kind follows the rules of SQLite for the affinity of a column.
+func (sqliteDialect) Kind(dataType string) string {
+	dataType = strings.ToUpper(dataType)
+	switch {
+	case strings.Contains(dataType, "INT"):
+		return "int"
+	case strings.Contains(dataType, "CHAR"), strings.Contains(dataType, "CLOB"), strings.Contains(dataType, "TEXT"):
+		return "string"
+	case strings.Contains(dataType, "REAL"), strings.Contains(dataType, "FLOA"), strings.Contains(dataType, "DOUB"):
+		return "float"
+	default:
+		return kindOf(dataType)
+	}
+}
+
+func (sqliteDialect) DefaultValues() string { return "DEFAULT VALUES" }
+
+func (d sqliteDialect) Returning(idColumn string) string { return " RETURNING " + d.Quote(idColumn) }
+
+func (sqliteDialect) EstimateQuery(table string) (string, []interface{}) { return "", nil }
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func PrepareTestApisSQLite(db *sql.DB) {
+	qs := []string{
+		`CREATE TABLE items (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  title varchar(255) NOT NULL,
+  description text NOT NULL,
+  updated varchar(255) DEFAULT NULL
+);`,
+
+		`INSERT INTO items (id, title, description, updated) VALUES
+(1,	'database/sql',	'Рассказать про базы данных',	'rvasily'),
+(2,	'memcache',	'Рассказать про мемкеш с примером использования',	NULL);`,
+
+		`CREATE TABLE users (
+  user_id INTEGER PRIMARY KEY AUTOINCREMENT,
+  login varchar(255) NOT NULL,
+  password varchar(255) NOT NULL,
+  email varchar(255) NOT NULL,
+  info text NOT NULL,
+  updated varchar(255) DEFAULT NULL
+);`,
+
+		`INSERT INTO users (user_id, login, password, email, info, updated) VALUES
+(1,	'rvasily',	'love',	'rvasily@example.com',	'none',	NULL);`,
+	}
+
+	for _, q := range qs {
+		_, err := db.Exec(q)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+func TestApisSQLite(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "dbcrud.sqlite"))
+	if err != nil {
+		t.Fatalf("cant open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	PrepareTestApisSQLite(db)
+
+	handler, err := NewDbExplorer(db)
+	if err != nil {
+		t.Fatalf("cant create explorer: %v", err)
+	}
+	if handler.(*DBExplorer).Dialect != SQLite {
+		t.Fatalf("expected sqlite dialect, got %s", handler.(*DBExplorer).Dialect.Name())
+	}
+
+	ts := httptest.NewServer(handler)
+	defer ts.Close()
+
+	runCases(t, ts, db, apiCases())
+
+	// a row of nothing but defaults has no columns to insert
+	if _, err = db.Exec(`CREATE TABLE notes (id INTEGER PRIMARY KEY AUTOINCREMENT, body text DEFAULT 'empty')`); err != nil {
+		t.Fatalf("cant create table: %v", err)
+	}
+	if _, err = handler.(*DBExplorer).ReloadSchema(); err != nil {
+		t.Fatalf("cant reload schema: %v", err)
+	}
+	runCases(t, ts, db, []Case{
+		{
+			Path:   "/notes/",
+			Method: http.MethodPut,
+			Body:   CR{},
+			Result: CR{"response": CR{"id": 1}},
+		},
+		{
+			Path:   "/notes/1",
+			Result: CR{"response": CR{"record": CR{"id": 1, "body": "empty"}}},
+		},
+	})
+}
+
+func TestSQLiteSchema(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "schema.sqlite"))
+	if err != nil {
+		t.Fatalf("cant open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	for _, q := range []string{
+		`CREATE TABLE items (id INTEGER PRIMARY KEY, title TEXT NOT NULL DEFAULT '', price REAL)`,
+		`CREATE TABLE tags (item_id INTEGER NOT NULL REFERENCES items(id), tag VARCHAR(20) NOT NULL, PRIMARY KEY (item_id, tag))`,
+	} {
+		if _, err = db.Exec(q); err != nil {
+			t.Fatalf("cant create tables: %v", err)
+		}
+	}
+
+	schema, err := LoadSchema(db, SQLite)
+	if err != nil {
+		t.Fatalf("cant load schema: %v", err)
+	}
+
+	items, _ := schema.Table("items")
+	id, _ := items.Column("id")
+	title, _ := items.Column("title")
+	price, _ := items.Column("price")
+	if !id.AutoIncrement || id.Nullable || items.IDColumn() != "id" || title.Kind != "string" || title.Default == nil || price.Kind != "float" || !price.Nullable {
+		t.Fatalf("unexpected items %+v %+v %+v %+v", items, id, title, price)
+	}
+
+	tags, _ := schema.Table("tags")
+	itemID, _ := tags.Column("item_id")
+	if strings.Join(tags.PrimaryKey, ",") != "item_id,tag" || tags.IDColumn() != "" || itemID.AutoIncrement ||
+		len(tags.ForeignKeys) != 1 || tags.ForeignKeys[0] != (ForeignKey{Column: "item_id", RefTable: "items", RefColumn: "id"}) {
+		t.Fatalf("unexpected tags %+v", tags)
+	}
+}
+
+func TestPostgresQueries(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("cant create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(PostgreSQL.TablesQuery()).
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}).AddRow("items"))
+	mock.ExpectQuery(PostgreSQL.ColumnsQuery()).
+		WillReturnRows(sqlmock.NewRows([]string{"table_name", "column_name", "data_type", "is_nullable", "column_default", "extra"}).
+			AddRow("items", "id", "integer", "NO", "nextval('items_id_seq'::regclass)", "auto_increment").
+			AddRow("items", "title", "character varying", "NO", nil, "").
+			AddRow("items", "updated", "text", "YES", nil, ""))
+	mock.ExpectQuery(PostgreSQL.KeysQuery()).
+		WillReturnRows(sqlmock.NewRows([]string{"table_name", "column_name", "constraint", "ref_table", "ref_column"}).
+			AddRow("items", "id", "PRIMARY", nil, nil))
+
+	dbe, err := NewDbExplorerWithDialect(db, PostgreSQL)
+	if err != nil {
+		t.Fatalf("cant create explorer: %v", err)
+	}
+
+	// placeholders are numbered in the order of the statement
+	mock.ExpectQuery(`SELECT * FROM "items" WHERE "title" = $1 AND (("id" > $2)) ORDER BY "id" ASC LIMIT $3 OFFSET $4`).
+		WithArgs("a", "7", 3, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT CASE WHEN reltuples < 0 THEN NULL ELSE reltuples::bigint END FROM pg_class WHERE oid = to_regclass($1)`).
+		WithArgs(`"items"`).
+		WillReturnRows(sqlmock.NewRows([]string{"reltuples"}).AddRow(nil))
+	mock.ExpectQuery(`SELECT COUNT(*) FROM "items"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`INSERT INTO "items" ("title") VALUES ($1) RETURNING "id"`).
+		WithArgs("new").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(8))
+	mock.ExpectExec(`UPDATE "items" SET "title" = $1, "updated" = $2 WHERE "id" = $3`).
+		WithArgs("x", nil, "8").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	qb := newQueryBuilder(dbe.Schema(), PostgreSQL)
+	cursor := encodeCursor([]rowsOrder{{Column: "id"}}, RowData{"id": 7})
+	if _, errResp := GetRows(db, qb, "items", &rowsQuery{Filters: []rowsFilter{{Column: "title", Op: "eq", Values: []string{"a"}}}, Limit: 2, Cursor: cursor, ByCursor: true}); errResp != nil {
+		t.Fatalf("cant get rows: %v", errResp.Error)
+	}
+	// no statistics yet, so it is counted
+	if _, errResp := CountRows(db, qb, "items", &rowsQuery{Count: "estimate"}); errResp != nil {
+		t.Fatalf("cant count rows: %v", errResp.Error)
+	}
+
+	for _, tc := range []struct {
+		Method string
+		Path   string
+		Body   string
+		Result string
+	}{
+		{http.MethodPut, "/items/", `{"title": "new", "id": 1}`, `{"response":{"id":8}}`},
+		{http.MethodPost, "/items/8", `{"title": "x", "updated": null}`, `{"response":{"updated":1}}`},
+	} {
+		w := httptest.NewRecorder()
+		dbe.ServeHTTP(w, httptest.NewRequest(tc.Method, tc.Path, strings.NewReader(tc.Body)))
+		if w.Body.String() != tc.Result {
+			t.Errorf("%s %s: expected %s, got %d %s", tc.Method, tc.Path, tc.Result, w.Code, w.Body)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKind(t *testing.T) {
+	for _, tc := range []struct {
+		Dialect  Dialect
+		DataType string
+		Kind     string
+	}{
+		{MySQL, "int", "int"},
+		{MySQL, "bigint", "int"},
+		{MySQL, "decimal", "float"},
+		{MySQL, "varchar", "string"},
+		{MySQL, "datetime", ""},
+		{PostgreSQL, "integer", "int"},
+		{PostgreSQL, "double precision", "float"},
+		{PostgreSQL, "character varying", "string"},
+		{PostgreSQL, "timestamp without time zone", ""},
+		{SQLite, "int(11)", "int"},
+		{SQLite, "UNSIGNED BIG INT", "int"},
+		{SQLite, "varchar(255)", "string"},
+		{SQLite, "DOUBLE PRECISION", "float"},
+		{SQLite, "BLOB", ""},
+	} {
+		if kind := tc.Dialect.Kind(tc.DataType); kind != tc.Kind {
+			t.Errorf("%s %q: expected %q, got %q", tc.Dialect.Name(), tc.DataType, tc.Kind, kind)
+		}
+	}
+}
+
+func TestPostgresTextID(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("cant create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(PostgreSQL.TablesQuery()).
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}).AddRow("tokens"))
+	mock.ExpectQuery(PostgreSQL.ColumnsQuery()).
+		WillReturnRows(sqlmock.NewRows([]string{"table_name", "column_name", "data_type", "is_nullable", "column_default", "extra"}).
+			AddRow("tokens", "id", "uuid", "NO", "gen_random_uuid()", "").
+			AddRow("tokens", "owner", "text", "NO", nil, ""))
+	mock.ExpectQuery(PostgreSQL.KeysQuery()).
+		WillReturnRows(sqlmock.NewRows([]string{"table_name", "column_name", "constraint", "ref_table", "ref_column"}).
+			AddRow("tokens", "id", "PRIMARY", nil, nil))
+
+	dbe, err := NewDbExplorerWithDialect(db, PostgreSQL)
+	if err != nil {
+		t.Fatalf("cant create explorer: %v", err)
+	}
+
+	// drivers hand the id out as text
+	mock.ExpectQuery(`INSERT INTO "tokens" ("owner") VALUES ($1) RETURNING "id"`).
+		WithArgs("rvasily").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow([]byte("8d3c1f0e-5a4b-4c2d-9e8f-7a6b5c4d3e2f")))
+
+	w := httptest.NewRecorder()
+	dbe.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/tokens/", strings.NewReader(`{"owner": "rvasily"}`)))
+	if expected := `{"response":{"id":"8d3c1f0e-5a4b-4c2d-9e8f-7a6b5c4d3e2f"}}`; w.Body.String() != expected {
+		t.Fatalf("expected %s, got %d %s", expected, w.Code, w.Body)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIDValue(t *testing.T) {
+	for idx, tc := range []struct {
+		ID       interface{}
+		Kind     string
+		Expected interface{}
+	}{
+		{int64(8), "int", int64(8)},
+		{[]byte("8"), "int", int64(8)},
+		{[]byte("8"), "string", "8"},
+		{"abc", "string", "abc"},
+		{[]byte("abc"), "", "abc"},
+	} {
+		if id := idValue(tc.ID, tc.Kind); id != tc.Expected {
+			t.Errorf("[%d] expected %#v, got %#v", idx, tc.Expected, id)
+		}
+	}
+}
+
+func TestDetectDialect(t *testing.T) {
+	sqliteDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("cant open sqlite: %v", err)
+	}
+	defer sqliteDB.Close()
+	mockDB, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("cant create sqlmock: %v", err)
+	}
+	defer mockDB.Close()
+
+	for _, tc := range []struct {
+		DB       *sql.DB
+		Expected Dialect
+	}{
+		{sqliteDB, SQLite},
+		{sql.OpenDB(pqConnector{}), PostgreSQL},
+		{mockDB, MySQL},
+	} {
+		if d := DetectDialect(tc.DB); d != tc.Expected {
+			t.Errorf("%T: expected %s, got %s", tc.DB.Driver(), tc.Expected.Name(), d.Name())
+		}
+	}
+
+	// drivers that are not linked into the tests
+	for pkgPath, expected := range map[string]Dialect{
+		"github.com/jackc/pgx/v5/stdlib": PostgreSQL,
+		"github.com/jackc/pgx/stdlib":    PostgreSQL,
+		"modernc.org/sqlite":             SQLite,
+		"github.com/go-sql-driver/mysql": MySQL,
+	} {
+		if d := dialectOfPackage(pkgPath); d != expected {
+			t.Errorf("%s: expected %s, got %s", pkgPath, expected.Name(), d.Name())
+		}
+	}
+}
+
+// pqConnector hands out the lib/pq driver without connecting anywhere.
+type pqConnector struct{}
+
+func (pqConnector) Connect(context.Context) (driver.Conn, error) {
+	return nil, errors.New("not connected")
+}
+
+func (pqConnector) Driver() driver.Driver { return pq.Driver{} }
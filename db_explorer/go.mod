@@ -2,4 +2,9 @@ module db_explorer
 
 go 1.24
 
-require github.com/go-sql-driver/mysql v1.7.1
+require (
+	github.com/DATA-DOG/go-sqlmock v1.5.2
+	github.com/go-sql-driver/mysql v1.7.1
+	github.com/lib/pq v1.10.9
+	github.com/mattn/go-sqlite3 v1.14.33
+)
@@ -0,0 +1,431 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// rowsQuery is what GET /$table asks for:
+//
+//	?fields=id,title&order_by=-updated,id&title__like=db%&id__in=1,2&limit=5&offset=0
+//	?order_by=title&cursor=eyJvIjoi...&count=exact
+//
+// Listings are paged by offset unless there is a cursor, an empty one for
+// the first page. A cursor does not go with an offset.
+//
+// Other parameters are filters: a column of t, or a column with an operator
+// after a double underscore. Column names with an operator are checked when
+// the query is built, the rest of the parameters, like a cache buster ?_=1,
+// are left alone.
+type rowsQuery struct {
+	Fields  []string
+	Filters []rowsFilter
+	OrderBy []rowsOrder
+	Limit   int
+	Offset  int
+	Count   string // exact or estimate, no total when empty
+
+	Cursor   string // next_cursor of the page before
+	ByCursor bool
+}
+
+type rowsFilter struct {
+	Column string
+	Op     string
+	Values []string
+}
+
+type rowsOrder struct {
+	Column string
+	Desc   bool
+}
+
+const defaultLimit = 5
+
+// filterOps are the operators of the filters and their SQL, "in" and
+// "isnull" are built separately.
+var filterOps = map[string]string{
+	"eq":     "=",
+	"ne":     "<>",
+	"gt":     ">",
+	"gte":    ">=",
+	"lt":     "<",
+	"lte":    "<=",
+	"like":   "LIKE",
+	"in":     "IN",
+	"isnull": "IS NULL",
+}
+
+func parseRowsQuery(values url.Values, t *Table) (*rowsQuery, *ResponseError) {
+	rq := &rowsQuery{}
+
+	// not a number is the default, like it always was
+	var err error
+	if rq.Limit, err = strconv.Atoi(values.Get("limit")); err != nil || rq.Limit < 0 {
+		rq.Limit = defaultLimit
+	}
+	if rq.Offset, err = strconv.Atoi(values.Get("offset")); err != nil || rq.Offset < 0 {
+		rq.Offset = 0
+	}
+
+	rq.Cursor, rq.ByCursor = values.Get("cursor"), values.Has("cursor")
+	if rq.ByCursor && rq.Offset != 0 {
+		return nil, &ResponseError{Error: "offset can not be used with cursor", StatusCode: http.StatusBadRequest}
+	}
+	rq.Count = values.Get("count")
+	if rq.Count != "" && rq.Count != "exact" && rq.Count != "estimate" {
+		return nil, &ResponseError{Error: "count must be exact or estimate", StatusCode: http.StatusBadRequest}
+	}
+
+	if fields := values.Get("fields"); fields != "" {
+		rq.Fields = strings.Split(fields, ",")
+	}
+
+	if orderBy := values.Get("order_by"); orderBy != "" {
+		for _, column := range strings.Split(orderBy, ",") {
+			order := rowsOrder{Column: column}
+			if strings.HasPrefix(column, "-") {
+				order = rowsOrder{Column: column[1:], Desc: true}
+			}
+			rq.OrderBy = append(rq.OrderBy, order)
+		}
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		switch key {
+		case "limit", "offset", "cursor", "count", "fields", "order_by":
+		default:
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		column, op := key, "eq"
+		if _, ok := t.Column(key); !ok {
+			i := strings.LastIndex(key, "__")
+			if i <= 0 {
+				continue
+			}
+			column, op = key[:i], key[i+2:]
+			if _, ok := filterOps[op]; !ok {
+				// a typo in the operator of a column is not a parameter of somebody else
+				if _, ok := t.Column(column); ok {
+					return nil, &ResponseError{Error: fmt.Sprintf("unknown filter operator %s", op), StatusCode: http.StatusBadRequest}
+				}
+				continue
+			}
+		}
+
+		for _, value := range values[key] {
+			f := rowsFilter{Column: column, Op: op, Values: []string{value}}
+			switch op {
+			case "in":
+				f.Values = strings.Split(value, ",")
+			case "isnull":
+				if value != "true" && value != "false" {
+					return nil, &ResponseError{Error: fmt.Sprintf("filter %s must be true or false", key), StatusCode: http.StatusBadRequest}
+				}
+			}
+			rq.Filters = append(rq.Filters, f)
+		}
+	}
+
+	return rq, nil
+}
+
+// rowsSelect is the query of a listing. Rows are asked one over the limit to
+// know if there is a next page.
+type rowsSelect struct {
+	Query string
+	Args  []interface{}
+	Keys  []rowsOrder // order of the cursor, nil when the listing cannot have one
+	Extra []string    // keys that are selected only for the cursor
+}
+
+func (qb *queryBuilder) selectRows(table string, rq *rowsQuery) (*rowsSelect, *ResponseError) {
+	t, quoted, errResp := qb.table(table)
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	rs := &rowsSelect{Keys: qb.keyset(t, rq.OrderBy)}
+
+	// the cursor needs the keys of the last row
+	fieldNames := rq.Fields
+	if len(fieldNames) != 0 && rq.ByCursor {
+		fieldNames = slices.Clone(fieldNames)
+		for _, key := range rs.Keys {
+			if !slices.Contains(fieldNames, key.Column) {
+				fieldNames = append(fieldNames, key.Column)
+				rs.Extra = append(rs.Extra, key.Column)
+			}
+		}
+	}
+
+	fields, errResp := qb.fields(t, fieldNames)
+	if errResp != nil {
+		return nil, errResp
+	}
+	args := qb.args()
+	where, errResp := qb.where(t, rq.Filters, args)
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	if rq.ByCursor && rs.Keys == nil {
+		return nil, &ResponseError{Error: "listing can not be paged by cursor", StatusCode: http.StatusBadRequest}
+	}
+	if rq.Cursor != "" {
+		values, errResp := decodeCursor(rq.Cursor, rs.Keys)
+		if errResp != nil {
+			return nil, errResp
+		}
+
+		cond := qb.after(rs.Keys, values, args)
+		if where == "" {
+			where = " WHERE " + cond
+		} else {
+			where += " AND " + cond
+		}
+	}
+
+	orders := rq.OrderBy
+	if rs.Keys != nil {
+		orders = rs.Keys
+	}
+	orderBy, errResp := qb.orderBy(t, orders)
+	if errResp != nil {
+		return nil, errResp
+	}
+
+	rs.Query = "SELECT " + fields + " FROM " + quoted + where + orderBy + " LIMIT " + args.add(rq.Limit+1) + " OFFSET " + args.add(rq.Offset)
+	rs.Args = args.values
+
+	return rs, nil
+}
+
+// countRows counts the rows that match the filters of rq.
+func (qb *queryBuilder) countRows(table string, rq *rowsQuery) (string, []interface{}, *ResponseError) {
+	t, quoted, errResp := qb.table(table)
+	if errResp != nil {
+		return "", nil, errResp
+	}
+	args := qb.args()
+	where, errResp := qb.where(t, rq.Filters, args)
+	if errResp != nil {
+		return "", nil, errResp
+	}
+
+	return "SELECT COUNT(*) FROM " + quoted + where, args.values, nil
+}
+
+// estimateRows is what the database thinks the size of table is, it knows
+// nothing about filters. The query is empty when the database can not tell.
+func (qb *queryBuilder) estimateRows(table string) (string, []interface{}, *ResponseError) {
+	t, _, errResp := qb.table(table)
+	if errResp != nil {
+		return "", nil, errResp
+	}
+
+	query, args := qb.dialect.EstimateQuery(t.Name)
+	return query, args, nil
+}
+
+// where is the WHERE clause of filters, empty when there are no filters.
+func (qb *queryBuilder) where(t *Table, filters []rowsFilter, args *queryArgs) (string, *ResponseError) {
+	if len(filters) == 0 {
+		return "", nil
+	}
+
+	conds := make([]string, 0, len(filters))
+	for _, f := range filters {
+		column, errResp := qb.column(t, f.Column)
+		if errResp != nil {
+			return "", errResp
+		}
+
+		switch f.Op {
+		case "in":
+			placeholders := make([]string, len(f.Values))
+			for i, value := range f.Values {
+				placeholders[i] = args.add(value)
+			}
+			conds = append(conds, column+" IN ("+strings.Join(placeholders, ", ")+")")
+		case "isnull":
+			if f.Values[0] == "true" {
+				conds = append(conds, column+" IS NULL")
+			} else {
+				conds = append(conds, column+" IS NOT NULL")
+			}
+		default:
+			conds = append(conds, column+" "+filterOps[f.Op]+" "+args.add(f.Values[0]))
+		}
+	}
+
+	return " WHERE " + strings.Join(conds, " AND "), nil
+}
+
+func (qb *queryBuilder) orderBy(t *Table, orders []rowsOrder) (string, *ResponseError) {
+	if len(orders) == 0 {
+		return "", nil
+	}
+
+	terms := make([]string, len(orders))
+	for i, order := range orders {
+		column, errResp := qb.column(t, order.Column)
+		if errResp != nil {
+			return "", errResp
+		}
+
+		terms[i] = column + " ASC"
+		if order.Desc {
+			terms[i] = column + " DESC"
+		}
+	}
+
+	return " ORDER BY " + strings.Join(terms, ", "), nil
+}
+
+func (qb *queryBuilder) fields(t *Table, fields []string) (string, *ResponseError) {
+	if len(fields) == 0 {
+		return "*", nil
+	}
+
+	columns := make([]string, len(fields))
+	for i, field := range fields {
+		column, errResp := qb.column(t, field)
+		if errResp != nil {
+			return "", errResp
+		}
+		columns[i] = column
+	}
+
+	return strings.Join(columns, ", "), nil
+}
+
+// keyset is orders with the primary key added to tell apart rows that are
+// equal otherwise. Rows can only be paged by a cursor when there is a
+// primary key and none of the columns are nullable, as NULL is neither
+// before nor after a value. The columns must be numbers or strings too,
+// which are the values a cursor keeps as they are.
+func (qb *queryBuilder) keyset(t *Table, orders []rowsOrder) []rowsOrder {
+	idColumnName := t.IDColumn()
+	if idColumnName == "" || !cursorColumn(t, idColumnName) {
+		return nil
+	}
+
+	keys := make([]rowsOrder, 0, len(orders)+1)
+	for _, order := range orders {
+		if !cursorColumn(t, order.Column) {
+			return nil
+		}
+		keys = append(keys, order)
+		if order.Column == idColumnName {
+			return keys
+		}
+	}
+
+	return append(keys, rowsOrder{Column: idColumnName})
+}
+
+func cursorColumn(t *Table, name string) bool {
+	col, ok := t.Column(name)
+	if !ok || col.Nullable {
+		return false
+	}
+
+	switch col.Kind {
+	case "int", "float", "string":
+		return true
+	default:
+		return false
+	}
+}
+
+// after is the condition for the rows after values in the order of keys,
+// spelled out as (a > ?) OR (a = ? AND b > ?) since the directions can differ.
+func (qb *queryBuilder) after(keys []rowsOrder, values []interface{}, args *queryArgs) string {
+	ors := make([]string, len(keys))
+	for i, key := range keys {
+		ands := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, qb.quote(keys[j].Column)+" = "+args.add(values[j]))
+		}
+
+		op := " > "
+		if key.Desc {
+			op = " < "
+		}
+		ands = append(ands, qb.quote(key.Column)+op+args.add(values[i]))
+
+		ors[i] = "(" + strings.Join(ands, " AND ") + ")"
+	}
+
+	return "(" + strings.Join(ors, " OR ") + ")"
+}
+
+type cursor struct {
+	Order  string        `json:"o"`
+	Values []interface{} `json:"v"`
+}
+
+func orderString(keys []rowsOrder) string {
+	columns := make([]string, len(keys))
+	for i, key := range keys {
+		columns[i] = key.Column
+		if key.Desc {
+			columns[i] = "-" + key.Column
+		}
+	}
+	return strings.Join(columns, ",")
+}
+
+func encodeCursor(keys []rowsOrder, row RowData) string {
+	c := cursor{Order: orderString(keys), Values: make([]interface{}, len(keys))}
+	for i, key := range keys {
+		c.Values[i] = row[key.Column]
+	}
+
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor is the values of keys in the cursor. A cursor only fits the
+// order it was made for.
+func decodeCursor(s string, keys []rowsOrder) ([]interface{}, *ResponseError) {
+	badCursor := &ResponseError{Error: "bad cursor", StatusCode: http.StatusBadRequest}
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, badCursor
+	}
+
+	c := cursor{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber() // ids over 2^53 stay as they are
+	if err = dec.Decode(&c); err != nil || c.Order != orderString(keys) || len(c.Values) != len(keys) {
+		return nil, badCursor
+	}
+
+	for i, value := range c.Values {
+		switch v := value.(type) {
+		case json.Number:
+			c.Values[i] = v.String()
+		case string:
+		default:
+			return nil, badCursor
+		}
+	}
+
+	return c.Values, nil
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestRowsQuery(t *testing.T) {
+	for idx, tc := range []struct {
+		Query  string
+		SQL    string
+		Args   []driver.Value
+		Status int
+	}{
+		{
+			Query: "",
+			SQL:   "SELECT * FROM `items` ORDER BY `id` ASC LIMIT ? OFFSET ?",
+			Args:  []driver.Value{6, 0},
+		},
+		{
+			Query: "fields=title,id&order_by=-updated,id&limit=2&offset=4",
+			SQL:   "SELECT `title`, `id` FROM `items` ORDER BY `updated` DESC, `id` ASC LIMIT ? OFFSET ?",
+			Args:  []driver.Value{3, 4},
+		},
+		{
+			Query: "id__gte=2&id__lt=10&title__like=data%25&updated__isnull=false&description=none",
+			SQL:   "SELECT * FROM `items` WHERE `description` = ? AND `id` >= ? AND `id` < ? AND `title` LIKE ? AND `updated` IS NOT NULL ORDER BY `id` ASC LIMIT ? OFFSET ?",
+			Args:  []driver.Value{"none", "2", "10", "data%", 6, 0},
+		},
+		{
+			Query: "id__in=1,2,3&title__ne=x&title__ne=y&updated__isnull=true",
+			SQL:   "SELECT * FROM `items` WHERE `id` IN (?, ?, ?) AND `title` <> ? AND `title` <> ? AND `updated` IS NULL ORDER BY `id` ASC LIMIT ? OFFSET ?",
+			Args:  []driver.Value{"1", "2", "3", "x", "y", 6, 0},
+		},
+		{Query: "title__regexp=.*", Status: http.StatusBadRequest},
+		{Query: "unknown__eq=1", Status: http.StatusBadRequest},
+		// what is neither a column nor has an operator is not a filter
+		{Query: "unknown=1&_=123&utm__source=x", SQL: "SELECT * FROM `items` ORDER BY `id` ASC LIMIT ? OFFSET ?", Args: []driver.Value{6, 0}},
+		{Query: "updated__isnull=maybe", Status: http.StatusBadRequest},
+		{Query: "fields=id,title%20FROM%20users%20--", Status: http.StatusBadRequest},
+		{Query: "fields=", SQL: "SELECT * FROM `items` ORDER BY `id` ASC LIMIT ? OFFSET ?", Args: []driver.Value{6, 0}},
+		{Query: "fields=id,", Status: http.StatusBadRequest},
+		{Query: "order_by=id%3BDROP%20TABLE%20items", Status: http.StatusBadRequest},
+		{Query: "order_by=(SELECT%20login%20FROM%20users)", Status: http.StatusBadRequest},
+		{Query: "order_by=--id", Status: http.StatusBadRequest},
+		{Query: "id%20OR%201%3D1--__eq=1", Status: http.StatusBadRequest},
+		{
+			Query: "title=x'%20OR%20'1'='1&id__in=1)%20OR%20(1",
+			SQL:   "SELECT * FROM `items` WHERE `id` IN (?) AND `title` = ? ORDER BY `id` ASC LIMIT ? OFFSET ?",
+			Args:  []driver.Value{"1) OR (1", "x' OR '1'='1", 6, 0},
+		},
+	} {
+		handler, mock := newMockExplorer(t)
+		if tc.SQL != "" {
+			mock.ExpectQuery(tc.SQL).
+				WithArgs(tc.Args...).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		}
+		if tc.Status == 0 {
+			tc.Status = http.StatusOK
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items?"+tc.Query, nil))
+
+		if w.Code != tc.Status {
+			t.Errorf("[%d] %s: expected status %d, got %d: %s", idx, tc.Query, tc.Status, w.Code, w.Body)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("[%d] %s: %v", idx, tc.Query, err)
+		}
+	}
+}
+
+func TestRowsCursor(t *testing.T) {
+	handler, mock := newMockExplorer(t)
+
+	request := func(query string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items?"+query, nil))
+
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if resp["response"] == nil {
+			return w.Code, resp
+		}
+		return w.Code, resp["response"].(map[string]interface{})
+	}
+
+	// the keys are selected for the cursor and left out of the records
+	mock.ExpectQuery("SELECT `description`, `title`, `id` FROM `items` ORDER BY `title` DESC, `id` ASC LIMIT ? OFFSET ?").
+		WithArgs(3, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"description", "title", "id"}).
+			AddRow("first", "c", 1).AddRow("second", "b", 2).AddRow("third", "b", 3))
+	mock.ExpectQuery("SELECT COUNT(*) FROM `items`").
+		WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(7))
+
+	status, resp := request("limit=2&order_by=-title&fields=description&count=exact&cursor=")
+	if status != http.StatusOK {
+		t.Fatalf("cant get first page: %d %v", status, resp)
+	}
+	expected := []interface{}{
+		map[string]interface{}{"description": "first"},
+		map[string]interface{}{"description": "second"},
+	}
+	if !reflect.DeepEqual(resp["records"], expected) || resp["total"] != float64(7) {
+		t.Fatalf("unexpected first page %v", resp)
+	}
+	next, _ := resp["next_cursor"].(string)
+	if next == "" {
+		t.Fatalf("no cursor for the next page: %v", resp)
+	}
+
+	mock.ExpectQuery("SELECT `description`, `title`, `id` FROM `items` WHERE ((`title` < ?) OR (`title` = ? AND `id` > ?)) ORDER BY `title` DESC, `id` ASC LIMIT ? OFFSET ?").
+		WithArgs("b", "b", "2", 3, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"description", "title", "id"}).AddRow("third", "b", 3))
+	mock.ExpectQuery("SELECT TABLE_ROWS FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?").
+		WithArgs("items").
+		WillReturnRows(sqlmock.NewRows([]string{"TABLE_ROWS"}).AddRow(6))
+
+	status, resp = request("limit=2&order_by=-title&fields=description&count=estimate&cursor=" + next)
+	if status != http.StatusOK || len(resp["records"].([]interface{})) != 1 || resp["next_cursor"] != nil || resp["total"] != float64(6) {
+		t.Fatalf("unexpected last page: %d %v", status, resp)
+	}
+
+	// filters are not in the statistics, paged by offset there is no cursor
+	mock.ExpectQuery("SELECT * FROM `items` WHERE `title` = ? ORDER BY `id` ASC LIMIT ? OFFSET ?").
+		WithArgs("b", 2, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2).AddRow(3))
+	mock.ExpectQuery("SELECT COUNT(*) FROM `items` WHERE `title` = ?").
+		WithArgs("b").
+		WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(2))
+	if status, resp = request("title=b&count=estimate&limit=1"); status != http.StatusOK || resp["total"] != float64(2) || resp["next_cursor"] != nil {
+		t.Fatalf("unexpected count: %d %v", status, resp)
+	}
+
+	tampered := base64.RawURLEncoding.EncodeToString([]byte(`{"o":"-title,id","v":[{"a":1},"2"]}`))
+	for _, query := range []string{
+		"order_by=title&cursor=" + next,
+		"order_by=-title&cursor=not-a-cursor",
+		"order_by=-title&cursor=" + tampered,
+		"order_by=updated&cursor=",
+		"cursor=&offset=2",
+		"count=all",
+	} {
+		if status, resp = request(query); status != http.StatusBadRequest {
+			t.Errorf("%s: expected bad request, got %d %v", query, status, resp)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRowsCursorKinds(t *testing.T) {
+	handler, mock := newMockExplorer(t,
+		[]driver.Value{"items", "price", "decimal", "NO", nil, ""},
+		[]driver.Value{"items", "created", "datetime", "NO", nil, ""})
+
+	request := func(query string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items?"+query, nil))
+
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if resp["response"] == nil {
+			return w.Code, resp
+		}
+		return w.Code, resp["response"].(map[string]interface{})
+	}
+
+	// numbers come back from the cursor as they went in
+	mock.ExpectQuery("SELECT * FROM `items` ORDER BY `price` DESC, `id` ASC LIMIT ? OFFSET ?").
+		WithArgs(2, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "price"}).AddRow(3, 9.5).AddRow(1, 7.25))
+	status, resp := request("order_by=-price&limit=1&cursor=")
+	next, _ := resp["next_cursor"].(string)
+	if status != http.StatusOK || next == "" {
+		t.Fatalf("unexpected first page: %d %v", status, resp)
+	}
+
+	mock.ExpectQuery("SELECT * FROM `items` WHERE ((`price` < ?) OR (`price` = ? AND `id` > ?)) ORDER BY `price` DESC, `id` ASC LIMIT ? OFFSET ?").
+		WithArgs("9.5", "9.5", "3", 2, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "price"}).AddRow(1, 7.25))
+	if status, resp = request("order_by=-price&limit=1&cursor=" + next); status != http.StatusOK {
+		t.Fatalf("unexpected last page: %d %v", status, resp)
+	}
+
+	// a time would not survive the cursor, it is paged by offset only
+	if status, resp = request("order_by=created&cursor="); status != http.StatusBadRequest {
+		t.Fatalf("expected bad request, got %d %v", status, resp)
+	}
+	mock.ExpectQuery("SELECT * FROM `items` ORDER BY `created` ASC LIMIT ? OFFSET ?").
+		WithArgs(6, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	if status, resp = request("order_by=created&offset=5"); status != http.StatusOK {
+		t.Fatalf("unexpected page: %d %v", status, resp)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -89,7 +89,8 @@ func TestApis(t *testing.T) {
 	db, err := sql.Open("mysql", DSN)
 	err = db.Ping()
 	if err != nil {
-		panic(err)
+		// TestApisSQLite runs the same cases without a server
+		t.Skipf("no mysql at %s: %v", DSN, err)
 	}
 
 	PrepareTestApis(db)
@@ -104,7 +105,12 @@ func TestApis(t *testing.T) {
 
 	ts := httptest.NewServer(handler)
 
-	cases := []Case{
+	runCases(t, ts, db, apiCases())
+}
+
+// apiCases are the same for every database
+func apiCases() []Case {
+	return []Case{
 		Case{
 			Path: "/", // список таблиц
 			Result: CR{
@@ -157,6 +163,23 @@ func TestApis(t *testing.T) {
 				},
 			},
 		},
+		// параметры, которые не поля и не фильтры, ничего не меняют
+		Case{
+			Path:  "/items",
+			Query: "limit=1&_=1582016000&utm_source=mail",
+			Result: CR{
+				"response": CR{
+					"records": []CR{
+						CR{
+							"id":          1,
+							"title":       "database/sql",
+							"description": "Рассказать про базы данных",
+							"updated":     "rvasily",
+						},
+					},
+				},
+			},
+		},
 		Case{
 			Path:  "/items",
 			Query: "limit=1&offset=1",
@@ -496,8 +519,6 @@ func TestApis(t *testing.T) {
 			},
 		},
 	}
-
-	runCases(t, ts, db, cases)
 }
 
 func runCases(t *testing.T, ts *httptest.Server, db *sql.DB, cases []Case) {
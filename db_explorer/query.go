@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// queryBuilder makes the statements of the explorer. Table and column names
+// come from the request, so they are looked up in the schema and quoted,
+// values only ever go through placeholders.
+type queryBuilder struct {
+	schema  *Schema
+	dialect Dialect
+}
+
+func newQueryBuilder(schema *Schema, dialect Dialect) *queryBuilder {
+	return &queryBuilder{schema: schema, dialect: dialect}
+}
+
+// queryArgs collects the arguments of a statement. Placeholders are numbered
+// in some dialects, so the parts of a statement are built in the order they
+// are in it.
+type queryArgs struct {
+	dialect Dialect
+	values  []interface{}
+}
+
+func (qb *queryBuilder) args() *queryArgs {
+	return &queryArgs{dialect: qb.dialect}
+}
+
+// add is the placeholder for value.
+func (a *queryArgs) add(value interface{}) string {
+	a.values = append(a.values, value)
+	return a.dialect.Placeholder(len(a.values))
+}
+
+func (qb *queryBuilder) quote(name string) string {
+	return qb.dialect.Quote(name)
+}
+
+func (qb *queryBuilder) table(table string) (*Table, string, *ResponseError) {
+	t, ok := qb.schema.Table(table)
+	if !ok {
+		return nil, "", &ResponseError{Error: "unknown table", StatusCode: http.StatusNotFound}
+	}
+	return t, qb.quote(t.Name), nil
+}
+
+func (qb *queryBuilder) column(t *Table, column string) (string, *ResponseError) {
+	if _, ok := t.Column(column); !ok {
+		return "", &ResponseError{Error: fmt.Sprintf("unknown field %s", column), StatusCode: http.StatusBadRequest}
+	}
+	return qb.quote(column), nil
+}
+
+// idColumn is the quoted primary key of t.
+func (qb *queryBuilder) idColumn(t *Table) (string, *ResponseError) {
+	idColumnName := t.IDColumn()
+	if idColumnName == "" {
+		return "", &ResponseError{Error: fmt.Sprintf("table %s has no single column primary key", t.Name)}
+	}
+	return qb.quote(idColumnName), nil
+}
+
+func (qb *queryBuilder) selectRow(table, id string) (string, []interface{}, *ResponseError) {
+	t, quoted, errResp := qb.table(table)
+	if errResp != nil {
+		return "", nil, errResp
+	}
+	idColumn, errResp := qb.idColumn(t)
+	if errResp != nil {
+		return "", nil, errResp
+	}
+
+	args := qb.args()
+	return "SELECT * FROM " + quoted + " WHERE " + idColumn + " = " + args.add(id), args.values, nil
+}
+
+// insertRow skips the fields that are not columns of table. The statement
+// returns the new id when the dialect has RETURNING and there is an id.
+func (qb *queryBuilder) insertRow(table string, rowData map[string]interface{}) (string, []interface{}, bool, *ResponseError) {
+	t, quoted, errResp := qb.table(table)
+	if errResp != nil {
+		return "", nil, false, errResp
+	}
+
+	args := qb.args()
+	columns := make([]string, 0, len(rowData))
+	placeholders := make([]string, 0, len(rowData))
+	for _, col := range t.Columns {
+		if val, ok := rowData[col.Name]; ok {
+			columns = append(columns, qb.quote(col.Name))
+			placeholders = append(placeholders, args.add(val))
+		}
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quoted, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+	if len(columns) == 0 {
+		query = "INSERT INTO " + quoted + " " + qb.dialect.DefaultValues()
+	}
+
+	returning := ""
+	if idColumnName := t.IDColumn(); idColumnName != "" {
+		returning = qb.dialect.Returning(idColumnName)
+	}
+	return query + returning, args.values, returning != "", nil
+}
+
+func (qb *queryBuilder) updateRow(table, id string, rowData map[string]interface{}) (string, []interface{}, *ResponseError) {
+	t, quoted, errResp := qb.table(table)
+	if errResp != nil {
+		return "", nil, errResp
+	}
+	idColumn, errResp := qb.idColumn(t)
+	if errResp != nil {
+		return "", nil, errResp
+	}
+
+	for colName := range rowData {
+		if _, ok := t.Column(colName); !ok {
+			return "", nil, &ResponseError{Error: "field doesn't exist", StatusCode: http.StatusBadRequest}
+		}
+	}
+	if len(rowData) == 0 {
+		return "", nil, &ResponseError{Error: "nothing to update", StatusCode: http.StatusBadRequest}
+	}
+
+	args := qb.args()
+	sets := make([]string, 0, len(rowData))
+	for _, col := range t.Columns {
+		if val, ok := rowData[col.Name]; ok {
+			sets = append(sets, qb.quote(col.Name)+" = "+args.add(val))
+		}
+	}
+
+	query := "UPDATE " + quoted + " SET " + strings.Join(sets, ", ") + " WHERE " + idColumn + " = " + args.add(id)
+	return query, args.values, nil
+}
+
+func (qb *queryBuilder) deleteRow(table, id string) (string, []interface{}, *ResponseError) {
+	t, quoted, errResp := qb.table(table)
+	if errResp != nil {
+		return "", nil, errResp
+	}
+	idColumn, errResp := qb.idColumn(t)
+	if errResp != nil {
+		return "", nil, errResp
+	}
+
+	args := qb.args()
+	return "DELETE FROM " + quoted + " WHERE " + idColumn + " = " + args.add(id), args.values, nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestHostileRequests(t *testing.T) {
+	itemColumns := []string{"id", "title", "description", "updated"}
+
+	for idx, tc := range []struct {
+		Method string
+		Path   string
+		Body   string
+		Expect func(mock sqlmock.Sqlmock)
+		Status int
+	}{
+		// names that are not in the schema never reach the database
+		{Path: "/items%3BDROP%20TABLE%20users", Status: http.StatusNotFound},
+		{Path: "/items%20WHERE%201=1%20--", Status: http.StatusNotFound},
+		{Path: "/information_schema.TABLES", Status: http.StatusNotFound},
+		{Path: "/users%60%3BDROP%20TABLE%20users%3B--/1", Status: http.StatusNotFound},
+		{Method: http.MethodDelete, Path: "/items/1/..%2Fusers", Status: http.StatusMethodNotAllowed},
+		{Method: http.MethodPost, Path: "/items", Body: `{"title": "x"}`, Status: http.StatusMethodNotAllowed},
+		{Method: http.MethodDelete, Path: "/", Status: http.StatusMethodNotAllowed},
+
+		// names are quoted even when they are known
+		{
+			Path: "/odd%60name",
+			Expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM `odd``name` LIMIT ? OFFSET ?").
+					WithArgs(6, 0).
+					WillReturnRows(sqlmock.NewRows([]string{"select"}))
+			},
+			Status: http.StatusOK,
+		},
+
+		// values go through placeholders
+		{
+			Path: "/items?limit=1%3BDROP%20TABLE%20items&offset=-1%20OR%201",
+			Expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM `items` ORDER BY `id` ASC LIMIT ? OFFSET ?").
+					WithArgs(6, 0).
+					WillReturnRows(sqlmock.NewRows(itemColumns))
+			},
+			Status: http.StatusOK,
+		},
+		{
+			Path: "/items/1%20OR%201=1",
+			Expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT * FROM `items` WHERE `id` = ?").
+					WithArgs("1 OR 1=1").
+					WillReturnRows(sqlmock.NewRows(itemColumns))
+			},
+			Status: http.StatusNotFound,
+		},
+		{
+			Method: http.MethodDelete,
+			Path:   "/users/1%27%20OR%20%271%27=%271",
+			Expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE FROM `users` WHERE `user_id` = ?").
+					WithArgs("1' OR '1'='1").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			Status: http.StatusOK,
+		},
+		{
+			Method: http.MethodPost,
+			Path:   "/items/1",
+			Body:   `{"title": "x', description = 'pwned", "updated": "1); DROP TABLE items; --"}`,
+			Expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE `items` SET `title` = ?, `updated` = ? WHERE `id` = ?").
+					WithArgs("x', description = 'pwned", "1); DROP TABLE items; --", "1").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			Status: http.StatusOK,
+		},
+		{
+			Method: http.MethodPut,
+			Path:   "/items/",
+			Body:   `{"title": "t", "description": "d", "id = 1; DROP TABLE items; --": 1, "` + "`title`" + `": "x"}`,
+			Expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO `items` (`title`, `description`) VALUES (?, ?)").
+					WithArgs("t", "d").
+					WillReturnResult(sqlmock.NewResult(3, 1))
+			},
+			Status: http.StatusOK,
+		},
+
+		// column names from the body are checked against the schema
+		{
+			Method: http.MethodPost,
+			Path:   "/items/1",
+			Body:   `{"title = 'a', description": "b"}`,
+			Status: http.StatusBadRequest,
+		},
+		{
+			Method: http.MethodPost,
+			Path:   "/items/1",
+			Body:   `{"title": "a", "id": 2}`,
+			Status: http.StatusBadRequest,
+		},
+	} {
+		handler, mock := newMockExplorer(t)
+		if tc.Expect != nil {
+			tc.Expect(mock)
+		}
+
+		method := tc.Method
+		if method == "" {
+			method = http.MethodGet
+		}
+		req := httptest.NewRequest(method, tc.Path, strings.NewReader(tc.Body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != tc.Status {
+			t.Errorf("[%d] %s %s: expected status %d, got %d: %s", idx, method, tc.Path, tc.Status, w.Code, w.Body)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("[%d] %s %s: %v", idx, method, tc.Path, err)
+		}
+	}
+}
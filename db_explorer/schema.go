@@ -0,0 +1,145 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Schema is what the explorer knows about the database. It is loaded once
+// by NewDbExplorer and shared by all requests until it is reloaded, a loaded
+// Schema is never changed.
+type Schema struct {
+	Tables []*Table `json:"tables"`
+
+	byName map[string]*Table
+}
+
+type Table struct {
+	Name        string       `json:"name"`
+	Columns     []*Column    `json:"columns"`
+	PrimaryKey  []string     `json:"primary_key,omitempty"`
+	ForeignKeys []ForeignKey `json:"foreign_keys,omitempty"`
+}
+
+type Column struct {
+	Name          string  `json:"name"`
+	Type          string  `json:"type"` // as the database has it, like int or varchar
+	Kind          string  `json:"kind"` // int, float, string or empty, see Dialect.Kind
+	Nullable      bool    `json:"nullable"`
+	Default       *string `json:"default,omitempty"`
+	AutoIncrement bool    `json:"auto_increment,omitempty"`
+}
+
+type ForeignKey struct {
+	Column    string `json:"column"`
+	RefTable  string `json:"ref_table"`
+	RefColumn string `json:"ref_column"`
+}
+
+func LoadSchema(db *sql.DB, dialect Dialect) (*Schema, error) {
+	tables, errResp := GetTables(db, dialect)
+	if errResp != nil {
+		return nil, fmt.Errorf("cant list tables: %s", errResp.Error)
+	}
+
+	s := &Schema{Tables: make([]*Table, 0, len(tables)), byName: make(map[string]*Table, len(tables))}
+	for _, name := range tables {
+		t := &Table{Name: name}
+		s.Tables = append(s.Tables, t)
+		s.byName[name] = t
+	}
+
+	if err := s.loadColumns(db, dialect); err != nil {
+		return nil, fmt.Errorf("cant list columns: %v", err)
+	}
+	if err := s.loadKeys(db, dialect); err != nil {
+		return nil, fmt.Errorf("cant list keys: %v", err)
+	}
+
+	return s, nil
+}
+
+func (s *Schema) loadColumns(db *sql.DB, dialect Dialect) error {
+	rows, err := db.Query(dialect.ColumnsQuery())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table, isNullable, extra string
+		col := &Column{}
+		if err = rows.Scan(&table, &col.Name, &col.Type, &isNullable, &col.Default, &extra); err != nil {
+			return err
+		}
+		col.Kind = dialect.Kind(col.Type)
+		col.Nullable = isNullable == "YES"
+		col.AutoIncrement = strings.Contains(extra, "auto_increment")
+
+		if t, ok := s.byName[table]; ok {
+			t.Columns = append(t.Columns, col)
+		}
+	}
+
+	return rows.Err()
+}
+
+func (s *Schema) loadKeys(db *sql.DB, dialect Dialect) error {
+	rows, err := db.Query(dialect.KeysQuery())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table, column, constraint string
+		var refTable, refColumn sql.NullString
+		if err = rows.Scan(&table, &column, &constraint, &refTable, &refColumn); err != nil {
+			return err
+		}
+
+		t, ok := s.byName[table]
+		if !ok {
+			continue
+		}
+		if constraint == "PRIMARY" {
+			t.PrimaryKey = append(t.PrimaryKey, column)
+		} else if refTable.Valid {
+			t.ForeignKeys = append(t.ForeignKeys, ForeignKey{Column: column, RefTable: refTable.String, RefColumn: refColumn.String})
+		}
+	}
+
+	return rows.Err()
+}
+
+func (s *Schema) Table(name string) (*Table, bool) {
+	t, ok := s.byName[name]
+	return t, ok
+}
+
+func (s *Schema) TableNames() []string {
+	names := make([]string, len(s.Tables))
+	for i, t := range s.Tables {
+		names[i] = t.Name
+	}
+	return names
+}
+
+func (t *Table) Column(name string) (*Column, bool) {
+	for _, col := range t.Columns {
+		if col.Name == name {
+			return col, true
+		}
+	}
+	return nil, false
+}
+
+// IDColumn is the column of /$table/$id, empty when the table has no primary
+// key or it spans several columns.
+func (t *Table) IDColumn() string {
+	if len(t.PrimaryKey) != 1 {
+		return ""
+	}
+	return t.PrimaryKey[0]
+}
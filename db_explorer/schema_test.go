@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// newMockExplorer starts the explorer on top of go-sqlmock. Queries are
+// matched as they are, so every case sees the exact SQL that is sent.
+func newMockExplorer(t *testing.T, extraColumns ...[]driver.Value) (http.Handler, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("cant create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	expectSchema(mock, extraColumns...)
+	handler, err := NewDbExplorer(db)
+	if err != nil {
+		t.Fatalf("cant create explorer: %v", err)
+	}
+
+	return handler, mock
+}
+
+func expectSchema(mock sqlmock.Sqlmock, extraColumns ...[]driver.Value) {
+	mock.ExpectQuery("SHOW TABLES").WillReturnRows(sqlmock.NewRows([]string{"Tables_in_dbcrud"}).
+		AddRow("comments").AddRow("items").AddRow("odd`name").AddRow("users"))
+
+	columns := sqlmock.NewRows([]string{"TABLE_NAME", "COLUMN_NAME", "DATA_TYPE", "IS_NULLABLE", "COLUMN_DEFAULT", "EXTRA"}).
+		AddRow("comments", "id", "int", "NO", nil, "auto_increment").
+		AddRow("comments", "item_id", "int", "NO", nil, "").
+		AddRow("comments", "body", "text", "NO", "''", "").
+		AddRow("items", "id", "int", "NO", nil, "auto_increment").
+		AddRow("items", "title", "varchar", "NO", nil, "").
+		AddRow("items", "description", "text", "NO", nil, "").
+		AddRow("items", "updated", "varchar", "YES", nil, "").
+		AddRow("odd`name", "select", "int", "YES", nil, "").
+		AddRow("users", "user_id", "int", "NO", nil, "auto_increment").
+		AddRow("users", "login", "varchar", "NO", nil, "")
+	for _, row := range extraColumns {
+		columns.AddRow(row...)
+	}
+	mock.ExpectQuery("SELECT TABLE_NAME, COLUMN_NAME, DATA_TYPE, IS_NULLABLE, COLUMN_DEFAULT, EXTRA FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = DATABASE() ORDER BY TABLE_NAME, ORDINAL_POSITION").
+		WillReturnRows(columns)
+
+	mock.ExpectQuery("SELECT TABLE_NAME, COLUMN_NAME, CONSTRAINT_NAME, REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME FROM information_schema.KEY_COLUMN_USAGE WHERE TABLE_SCHEMA = DATABASE() ORDER BY TABLE_NAME, CONSTRAINT_NAME, ORDINAL_POSITION").
+		WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME", "COLUMN_NAME", "CONSTRAINT_NAME", "REFERENCED_TABLE_NAME", "REFERENCED_COLUMN_NAME"}).
+			AddRow("comments", "id", "PRIMARY", nil, nil).
+			AddRow("comments", "item_id", "comments_item", "items", "id").
+			AddRow("items", "id", "PRIMARY", nil, nil).
+			AddRow("users", "user_id", "PRIMARY", nil, nil))
+}
+
+func TestLoadSchema(t *testing.T) {
+	handler, mock := newMockExplorer(t)
+	schema := handler.(*DBExplorer).Schema()
+
+	if names := schema.TableNames(); !reflect.DeepEqual(names, []string{"comments", "items", "odd`name", "users"}) {
+		t.Fatalf("unexpected tables %v", names)
+	}
+
+	comments, _ := schema.Table("comments")
+	emptyText := "''"
+	expected := &Table{
+		Name: "comments",
+		Columns: []*Column{
+			{Name: "id", Type: "int", Kind: "int", AutoIncrement: true},
+			{Name: "item_id", Type: "int", Kind: "int"},
+			{Name: "body", Type: "text", Kind: "string", Default: &emptyText},
+		},
+		PrimaryKey:  []string{"id"},
+		ForeignKeys: []ForeignKey{{Column: "item_id", RefTable: "items", RefColumn: "id"}},
+	}
+	if !reflect.DeepEqual(comments, expected) {
+		t.Fatalf("comments dont match\nhave %+v\nwant %+v", comments, expected)
+	}
+
+	odd, _ := schema.Table("odd`name")
+	if col, ok := odd.Column("select"); !ok || !col.Nullable || odd.IDColumn() != "" {
+		t.Fatalf("unexpected table %+v", odd)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSchemaReload(t *testing.T) {
+	handler, mock := newMockExplorer(t)
+
+	token := ""
+	request := func(method, path string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		handler.ServeHTTP(w, r)
+
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	// serving the schema and the tables asks nothing from the database
+	if status, resp := request(http.MethodGet, "/_schema"); status != http.StatusOK || resp["response"] == nil {
+		t.Fatalf("cant get schema: %d %v", status, resp)
+	}
+	if status, _ := request(http.MethodGet, "/"); status != http.StatusOK {
+		t.Fatalf("cant get tables: %d", status)
+	}
+	// without a token nobody can make the explorer reload
+	if status, _ := request(http.MethodPost, "/_schema/reload"); status != http.StatusForbidden {
+		t.Fatalf("expected reload to be disabled, got %d", status)
+	}
+	handler.(*DBExplorer).ReloadToken = "secret"
+	token = "guess"
+	if status, _ := request(http.MethodPost, "/_schema/reload"); status != http.StatusUnauthorized {
+		t.Fatalf("expected reload to need the token, got %d", status)
+	}
+	token = "secret"
+	if status, _ := request(http.MethodGet, "/_schema/reload"); status != http.StatusMethodNotAllowed {
+		t.Fatalf("expected reload to need POST, got %d", status)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a failed reload keeps what there was
+	mock.ExpectQuery("SHOW TABLES").WillReturnError(errors.New("server has gone away"))
+	if status, _ := request(http.MethodPost, "/_schema/reload"); status != http.StatusInternalServerError {
+		t.Fatalf("expected reload to fail, got %d", status)
+	}
+	if _, ok := handler.(*DBExplorer).Schema().Table("items"); !ok {
+		t.Fatalf("schema is lost after a failed reload")
+	}
+
+	expectSchema(mock, []driver.Value{"users", "email", "varchar", "NO", nil, ""})
+	if status, _ := request(http.MethodPost, "/_schema/reload"); status != http.StatusOK {
+		t.Fatalf("cant reload schema: %d", status)
+	}
+	users, _ := handler.(*DBExplorer).Schema().Table("users")
+	if _, ok := users.Column("email"); !ok {
+		t.Fatalf("new column is not seen after reload: %+v", users)
+	}
+
+	// the new column can be written right away
+	mock.ExpectExec("UPDATE `users` SET `email` = ? WHERE `user_id` = ?").
+		WithArgs("a@example.com", "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users/1", bytes.NewBufferString(`{"email": "a@example.com"}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("cant update new column: %d %s", w.Code, w.Body)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}